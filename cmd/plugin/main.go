@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,6 +17,10 @@ type CompileConfig struct {
 	OutputPath string       `json:"outputPath"`
 	Config     PluginConfig `json:"config,omitempty"`
 	Verbose    bool         `json:"verbose,omitempty"`
+	// Check compares the generated output with the files under outputPath instead of writing it
+	Check bool `json:"check,omitempty"`
+	// Force overwrites generated files that were edited outside their custom regions
+	Force bool `json:"force,omitempty"`
 }
 
 // PluginConfig represents the SQLAlchemy-specific configuration
@@ -27,14 +32,41 @@ type PluginConfig struct {
 	AddTypeHints    *bool  `json:"addTypeHints,omitempty"`
 	GenerateInit    *bool  `json:"generateInit,omitempty"`
 	IndentSize      *int   `json:"indentSize,omitempty"`
+	SingleFile      *bool  `json:"singleFile,omitempty"`
 	TableNamePrefix string `json:"tableNamePrefix,omitempty"`
 	TableNameSuffix string `json:"tableNameSuffix,omitempty"`
 
+	// Table naming strategy and plural overrides for snake_plural
+	TableNaming string            `json:"tableNaming,omitempty"`
+	Irregulars  map[string]string `json:"irregulars,omitempty"`
+
+	// Column naming strategy and explicit column names per model field
+	ColumnNaming string                       `json:"columnNaming,omitempty"`
+	ColumnNames  map[string]map[string]string `json:"columnNames,omitempty"`
+
+	// Structure field storage and mutation tracking
+	StructureStorage       string                       `json:"structureStorage,omitempty"`
+	StructureStorageFields map[string]map[string]string `json:"structureStorageFields,omitempty"`
+	MutableStructures      *bool                        `json:"mutableStructures,omitempty"`
+
+	// Database schema and per-model table overrides
+	DefaultSchema string                     `json:"defaultSchema,omitempty"`
+	Tables        map[string]cfg.TableConfig `json:"tables,omitempty"`
+
+	// Constraint naming convention merged over the defaults
+	NamingConvention map[string]string `json:"namingConvention,omitempty"`
+
 	// Type-specific configurations
 	Enums      cfg.EnumConfig      `json:"enums,omitempty"`
 	Models     cfg.ModelConfig     `json:"models,omitempty"`
 	Structures cfg.StructureConfig `json:"structures,omitempty"`
 	Entities   cfg.EntityConfig    `json:"entities,omitempty"`
+
+	// Migration generation
+	Migrations cfg.MigrationConfig `json:"migrations,omitempty"`
+
+	// SQL DDL output
+	DDL cfg.DDLConfig `json:"ddl,omitempty"`
 }
 
 // Exit codes
@@ -45,6 +77,7 @@ const (
 	ExitInvalidConfig   = 4
 	ExitInputPathError  = 12
 	ExitOutputPathError = 13
+	ExitStaleOutput     = 14
 )
 
 // logInfo prints info messages only when verbose mode is enabled
@@ -134,6 +167,56 @@ func main() {
 		logInfo(compileConfig.Verbose, "Table name suffix: %s", compileConfig.Config.TableNameSuffix)
 	}
 
+	if compileConfig.Config.TableNaming != "" {
+		morpheConfig.FormatConfig.TableNaming = compileConfig.Config.TableNaming
+		logInfo(compileConfig.Verbose, "Table naming: %s", compileConfig.Config.TableNaming)
+	}
+
+	if len(compileConfig.Config.Irregulars) > 0 {
+		morpheConfig.FormatConfig.Irregulars = compileConfig.Config.Irregulars
+		logInfo(compileConfig.Verbose, "Irregular plurals: %v", compileConfig.Config.Irregulars)
+	}
+
+	if compileConfig.Config.ColumnNaming != "" {
+		morpheConfig.FormatConfig.ColumnNaming = compileConfig.Config.ColumnNaming
+		logInfo(compileConfig.Verbose, "Column naming: %s", compileConfig.Config.ColumnNaming)
+	}
+
+	if len(compileConfig.Config.ColumnNames) > 0 {
+		morpheConfig.FormatConfig.ColumnNames = compileConfig.Config.ColumnNames
+		logInfo(compileConfig.Verbose, "Column name overrides: %d models", len(compileConfig.Config.ColumnNames))
+	}
+
+	if compileConfig.Config.StructureStorage != "" {
+		morpheConfig.FormatConfig.StructureStorage = compileConfig.Config.StructureStorage
+		logInfo(compileConfig.Verbose, "Structure storage: %s", compileConfig.Config.StructureStorage)
+	}
+
+	if len(compileConfig.Config.StructureStorageFields) > 0 {
+		morpheConfig.FormatConfig.StructureStorageFields = compileConfig.Config.StructureStorageFields
+		logInfo(compileConfig.Verbose, "Structure storage overrides: %d models", len(compileConfig.Config.StructureStorageFields))
+	}
+
+	if compileConfig.Config.MutableStructures != nil {
+		morpheConfig.FormatConfig.MutableStructures = *compileConfig.Config.MutableStructures
+		logInfo(compileConfig.Verbose, "Mutable structures: %v", *compileConfig.Config.MutableStructures)
+	}
+
+	if compileConfig.Config.DefaultSchema != "" {
+		morpheConfig.FormatConfig.DefaultSchema = compileConfig.Config.DefaultSchema
+		logInfo(compileConfig.Verbose, "Default schema: %s", compileConfig.Config.DefaultSchema)
+	}
+
+	if len(compileConfig.Config.Tables) > 0 {
+		morpheConfig.FormatConfig.Tables = compileConfig.Config.Tables
+		logInfo(compileConfig.Verbose, "Table overrides: %d models", len(compileConfig.Config.Tables))
+	}
+
+	if len(compileConfig.Config.NamingConvention) > 0 {
+		morpheConfig.FormatConfig.NamingConvention = compileConfig.Config.NamingConvention
+		logInfo(compileConfig.Verbose, "Naming convention overrides: %v", compileConfig.Config.NamingConvention)
+	}
+
 	// Type hints
 	if compileConfig.Config.AddTypeHints != nil {
 		morpheConfig.FormatConfig.AddTypeHints = *compileConfig.Config.AddTypeHints
@@ -152,11 +235,19 @@ func main() {
 		logInfo(compileConfig.Verbose, "Indent size: %d", *compileConfig.Config.IndentSize)
 	}
 
+	// Output layout
+	if compileConfig.Config.SingleFile != nil {
+		morpheConfig.FormatConfig.SingleFile = *compileConfig.Config.SingleFile
+		logInfo(compileConfig.Verbose, "Single file output: %v", *compileConfig.Config.SingleFile)
+	}
+
 	// Apply type-specific configurations
 	morpheConfig.MorpheConfig.Enums = compileConfig.Config.Enums
 	morpheConfig.MorpheConfig.Models = compileConfig.Config.Models
 	morpheConfig.MorpheConfig.Structures = compileConfig.Config.Structures
 	morpheConfig.MorpheConfig.Entities = compileConfig.Config.Entities
+	morpheConfig.Migrations = compileConfig.Config.Migrations
+	morpheConfig.DDL = compileConfig.Config.DDL
 
 	// Log type-specific configs if verbose
 	if compileConfig.Verbose {
@@ -169,8 +260,23 @@ func main() {
 		if compileConfig.Config.Entities.LazyLoadingStyle != "" {
 			logInfo(true, "Entity lazy loading style: %s", compileConfig.Config.Entities.LazyLoadingStyle)
 		}
+		if compileConfig.Config.Migrations.Enabled {
+			logInfo(true, "Migrations enabled (previous registry: '%s', previous schema: '%s')",
+				compileConfig.Config.Migrations.PreviousRegistryPath, compileConfig.Config.Migrations.PreviousSchemaPath)
+		}
+		if compileConfig.Config.DDL.Enabled {
+			logInfo(true, "SQL DDL enabled (dialect: %s)", compileConfig.Config.DDL.GetDialect())
+		}
 	}
 
+	// Check mode
+	if compileConfig.Check {
+		morpheConfig.Check = true
+		logInfo(compileConfig.Verbose, "Check mode: comparing the output with '%s'", compileConfig.OutputPath)
+	}
+
+	morpheConfig.Force = compileConfig.Force
+
 	// Validate configuration
 	if err := morpheConfig.Validate(); err != nil {
 		fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
@@ -180,6 +286,16 @@ func main() {
 	// Run compilation
 	logInfo(compileConfig.Verbose, "Starting compilation process...")
 	if err := compile.MorpheToSQLAlchemy(morpheConfig); err != nil {
+		// Table overrides can only be checked against the loaded registry
+		if errors.Is(err, compile.ErrInvalidTableConfig) {
+			fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
+			os.Exit(ExitInvalidConfig)
+		}
+		// Check mode has printed the drift already
+		if errors.Is(err, compile.ErrStaleOutput) {
+			fmt.Fprintln(os.Stderr, "Check failed:", err)
+			os.Exit(ExitStaleOutput)
+		}
 		fmt.Fprintln(os.Stderr, "Compilation failed:", err)
 		os.Exit(ExitCompileFailed)
 	}
@@ -7,6 +7,7 @@ require (
 	github.com/kalo-build/go v0.0.0-20250329083200-af53fba2b8e5
 	github.com/kalo-build/go-util v0.0.0-20250329083327-00e97aeff9b7
 	github.com/kalo-build/morphe-go v0.0.0-20250824082856-62352ec5b6a9
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.9.0
 )
 
@@ -14,7 +15,6 @@ require (
 	github.com/barkimedes/go-deepcopy v0.0.0-20220514131651-17c30cfc62df // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/gobeam/stringy v0.0.7 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,31 @@
+package cfg
+
+import (
+	"fmt"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/typemap"
+)
+
+// DDLConfig contains configuration for raw SQL schema output
+type DDLConfig struct {
+	// Enabled writes the schema as SQL DDL to schema.sql
+	Enabled bool `json:"enabled,omitempty"`
+	// Dialect selects the SQL dialect
+	Dialect string `json:"dialect,omitempty"` // "postgresql", "mysql", "sqlite"
+}
+
+// GetDialect returns the configured dialect, defaulting to PostgreSQL
+func (config DDLConfig) GetDialect() string {
+	if config.Dialect == "" {
+		return typemap.DialectPostgreSQL
+	}
+	return config.Dialect
+}
+
+// Validate checks if the configuration is valid
+func (config DDLConfig) Validate() error {
+	if config.Dialect != "" && !typemap.IsSupportedDialect(config.Dialect) {
+		return fmt.Errorf("invalid DDL dialect: %s (must be 'postgresql', 'mysql', or 'sqlite')", config.Dialect)
+	}
+	return nil
+}
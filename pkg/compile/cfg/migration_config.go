@@ -0,0 +1,30 @@
+package cfg
+
+import "fmt"
+
+// MigrationConfig contains configuration for Alembic migration generation
+type MigrationConfig struct {
+	// Enabled generates an Alembic revision from the schema diff
+	Enabled bool `json:"enabled,omitempty"`
+	// PreviousRegistryPath is a registry snapshot directory to diff against
+	PreviousRegistryPath string `json:"previousRegistryPath,omitempty"`
+	// PreviousSchemaPath is an exported schema JSON file to diff against
+	PreviousSchemaPath string `json:"previousSchemaPath,omitempty"`
+	// DownRevision is the Alembic revision the generated revision builds on
+	DownRevision string `json:"downRevision,omitempty"`
+	// Message is the revision message
+	Message string `json:"message,omitempty"`
+	// ExportSchema writes the current schema to schema.json for future diffs
+	ExportSchema bool `json:"exportSchema,omitempty"`
+}
+
+// Validate checks if the configuration is valid
+func (config MigrationConfig) Validate() error {
+	if config.PreviousRegistryPath != "" && config.PreviousSchemaPath != "" {
+		return fmt.Errorf("only one of previousRegistryPath and previousSchemaPath can be set")
+	}
+	if !config.Enabled && (config.PreviousRegistryPath != "" || config.PreviousSchemaPath != "") {
+		return fmt.Errorf("a previous schema snapshot requires migrations to be enabled")
+	}
+	return nil
+}
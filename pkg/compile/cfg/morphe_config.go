@@ -28,6 +28,8 @@ type ModelConfig struct {
 	GenerateExamples bool `json:"generateExamples,omitempty"`
 	// UseValidators generates Pydantic validators for common patterns
 	UseValidators bool `json:"useValidators,omitempty"`
+	// IdentityHelpers generates identity_key() and get_by_ classmethods from the model identifiers
+	IdentityHelpers bool `json:"identityHelpers,omitempty"`
 }
 
 // StructureConfig contains configuration specific to structure generation
@@ -36,6 +38,8 @@ type StructureConfig struct {
 	UseDataclass bool `json:"useDataclass,omitempty"`
 	// GenerateSlots adds __slots__ for memory efficiency
 	GenerateSlots bool `json:"generateSlots,omitempty"`
+	// Frozen makes dataclass structures immutable
+	Frozen bool `json:"frozen,omitempty"`
 }
 
 // EntityConfig contains configuration specific to entity generation
@@ -46,6 +50,10 @@ type EntityConfig struct {
 	LazyLoadingStyle string `json:"lazyLoadingStyle,omitempty"` // "async", "sync", "property"
 	// IncludeValidation adds validation methods
 	IncludeValidation bool `json:"includeValidation,omitempty"`
+	// IdentityEquality compares and hashes entities by their primary identifier
+	IdentityEquality bool `json:"identityEquality,omitempty"`
+	// Views lists the entities served by a database view
+	Views []string `json:"views,omitempty"`
 }
 
 // Validate checks if the configuration is valid
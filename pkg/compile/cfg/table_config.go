@@ -0,0 +1,13 @@
+package cfg
+
+// TableConfig contains per-model table overrides
+type TableConfig struct {
+	// Name is an explicit table name, used as is without prefix or suffix
+	Name string `json:"name,omitempty"`
+	// Schema is the database schema of the table, overriding the default schema
+	Schema string `json:"schema,omitempty"`
+	// Comment is attached to the table in the database
+	Comment string `json:"comment,omitempty"`
+	// Skip excludes the model from generation because its table is managed elsewhere
+	Skip bool `json:"skip,omitempty"`
+}
@@ -0,0 +1,110 @@
+package compile
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// checkOutput compares the files generated in memory with the files under the output path,
+// printing a unified diff for each file that is missing, differs or would be pruned
+func checkOutput(outputPath string, files map[string][]byte, pruned []string) error {
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	staleCount := 0
+	for _, path := range paths {
+		diff, err := diffOutputFile(outputPath, path, files[path])
+		if err != nil {
+			return err
+		}
+		if diff != "" {
+			fmt.Print(diff)
+			staleCount++
+		}
+	}
+
+	for _, path := range pruned {
+		diff, err := diffPrunedFile(outputPath, path)
+		if err != nil {
+			return err
+		}
+		fmt.Print(diff)
+		staleCount++
+	}
+
+	if staleCount > 0 {
+		return ErrStaleOutputFiles(staleCount)
+	}
+	return nil
+}
+
+// diffOutputFile returns the unified diff from a file on disk to its generated content, or "" if they match
+func diffOutputFile(outputPath string, path string, generated []byte) (string, error) {
+	fromFile := path
+	if relativePath, err := filepath.Rel(outputPath, path); err == nil {
+		fromFile = relativePath
+	}
+	toFile := fromFile
+
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fromFile = "/dev/null"
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if string(existing) == string(generated) && fromFile != "/dev/null" {
+		return "", nil
+	}
+
+	var existingLines []string
+	if len(existing) > 0 {
+		existingLines = difflib.SplitLines(string(existing))
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        existingLines,
+		B:        difflib.SplitLines(string(generated)),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", path, err)
+	}
+	return diff, nil
+}
+
+// diffPrunedFile returns the unified diff removing a file that is no longer generated
+func diffPrunedFile(outputPath string, path string) (string, error) {
+	fromFile := path
+	if relativePath, err := filepath.Rel(outputPath, path); err == nil {
+		fromFile = relativePath
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var existingLines []string
+	if len(existing) > 0 {
+		existingLines = difflib.SplitLines(string(existing))
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        existingLines,
+		FromFile: fromFile,
+		ToFile:   "/dev/null",
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", path, err)
+	}
+	return diff, nil
+}
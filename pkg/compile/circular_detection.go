@@ -2,6 +2,7 @@ package compile
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/kalo-build/morphe-go/pkg/yaml"
@@ -40,6 +41,44 @@ func DetectCircularDependencies(models map[string]yaml.Model) []CircularDependen
 	return dedupeCycles(cycles)
 }
 
+// DetectStructureCycles checks for structures that contain themselves through their fields.
+// Unlike model relationships these can't be broken up, since structures nest by value.
+func DetectStructureCycles(structures map[string]yaml.Structure) []CircularDependency {
+	var structureNames []string
+	for structureName := range structures {
+		structureNames = append(structureNames, structureName)
+	}
+	sort.Strings(structureNames)
+
+	graph := make(map[string][]string)
+	for _, structureName := range structureNames {
+		var fieldNames []string
+		for fieldName := range structures[structureName].Fields {
+			fieldNames = append(fieldNames, fieldName)
+		}
+		sort.Strings(fieldNames)
+
+		deps := []string{}
+		for _, fieldName := range fieldNames {
+			fieldType := string(structures[structureName].Fields[fieldName].Type)
+			if _, isStructure := structures[fieldType]; isStructure && !contains(deps, fieldType) {
+				deps = append(deps, fieldType)
+			}
+		}
+		graph[structureName] = deps
+	}
+
+	var cycles []CircularDependency
+	visited := make(map[string]bool)
+	recStack := make(map[string]bool)
+	for _, structureName := range structureNames {
+		if !visited[structureName] {
+			cycles = append(cycles, dfsDetectCycles(structureName, graph, visited, recStack, []string{})...)
+		}
+	}
+	return dedupeCycles(cycles)
+}
+
 // buildDependencyGraph creates an adjacency list of model dependencies
 func buildDependencyGraph(models map[string]yaml.Model) map[string][]string {
 	graph := make(map[string][]string)
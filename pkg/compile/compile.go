@@ -15,8 +15,30 @@ func MorpheToSQLAlchemy(config MorpheCompileConfig) error {
 		return fmt.Errorf("failed to load morphe registry: %w", rErr)
 	}
 
-	// Initialize the writer
+	// Table overrides must refer to models in the registry and table names must be unique
+	if err := validateTableConfigs(config.FormatConfig, r); err != nil {
+		return err
+	}
+	if err := validateTableNames(config.FormatConfig, r); err != nil {
+		return err
+	}
+	if err := validateEntityViews(config, r); err != nil {
+		return err
+	}
+
+	// Initialize the writer, which stages the output until every phase succeeded.
+	// In check mode the output is only compared with the files on disk.
 	writer := NewMorpheWriter(config.OutputPath)
+	if config.Check {
+		writer = NewMemoryMorpheWriter(config.OutputPath)
+	}
+	writer.UseMultiFile = !config.FormatConfig.SingleFile
+	writer.Force = config.Force
+	sources, err := loadRegistrySources(config.MorpheLoadRegistryConfig)
+	if err != nil {
+		return fmt.Errorf("failed to locate registry files: %w", err)
+	}
+	writer.sources = sources
 
 	// Process enums if present
 	if r.HasEnums() {
@@ -41,7 +63,7 @@ func MorpheToSQLAlchemy(config MorpheCompileConfig) error {
 		// For SQLAlchemy, generate the base.py file first
 		if config.FormatConfig.UseDeclarative {
 			fmt.Println("Generating base.py...")
-			if err := writer.WriteBaseFile(); err != nil {
+			if err := writer.WriteBaseFile(generateBaseContent(config.FormatConfig)); err != nil {
 				return fmt.Errorf("failed to write base.py: %w", err)
 			}
 		}
@@ -52,6 +74,22 @@ func MorpheToSQLAlchemy(config MorpheCompileConfig) error {
 		}
 	}
 
+	// Diff the schema against the previous snapshot if requested
+	if config.Migrations.Enabled || config.Migrations.ExportSchema {
+		fmt.Println("Compiling migrations...")
+		if err := CompileMigration(config, r, writer); err != nil {
+			return fmt.Errorf("failed to compile migrations: %w", err)
+		}
+	}
+
+	// Render the schema as SQL DDL if requested
+	if config.DDL.Enabled {
+		fmt.Println("Compiling SQL schema...")
+		if err := CompileDDL(config, r, writer); err != nil {
+			return fmt.Errorf("failed to compile SQL schema: %w", err)
+		}
+	}
+
 	// Process structures if present
 	if r.HasStructures() {
 		fmt.Println("Compiling structures...")
@@ -81,8 +119,31 @@ func MorpheToSQLAlchemy(config MorpheCompileConfig) error {
 		if err := CompileAllEntities(config, r, writer); err != nil {
 			return fmt.Errorf("failed to compile entities: %w", err)
 		}
+
+		if config.MorpheConfig.Entities.GenerateRepository {
+			fmt.Println("Compiling repositories...")
+			if err := CompileAllRepositories(config, r, writer); err != nil {
+				return fmt.Errorf("failed to compile repositories: %w", err)
+			}
+		}
 	}
 
+	// Prune the files of the previous run that are no longer generated
+	pruned, err := writer.WriteManifest()
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if config.Check {
+		fmt.Println("Checking generated output...")
+		return checkOutput(config.OutputPath, writer.Files(), pruned)
+	}
+	if err := writer.Commit(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	for _, path := range pruned {
+		fmt.Printf("Pruned %s\n", path)
+	}
 	return nil
 }
 
@@ -0,0 +1,305 @@
+package compile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/typemap"
+)
+
+// sqlReservedWords are identifiers that must be quoted in DDL
+var sqlReservedWords = map[string]bool{
+	"all": true, "and": true, "as": true, "asc": true, "between": true, "by": true,
+	"case": true, "check": true, "column": true, "constraint": true, "create": true,
+	"default": true, "desc": true, "distinct": true, "drop": true, "else": true,
+	"end": true, "foreign": true, "from": true, "group": true, "having": true,
+	"in": true, "index": true, "is": true, "join": true, "key": true, "like": true,
+	"limit": true, "not": true, "null": true, "on": true, "or": true, "order": true,
+	"primary": true, "references": true, "select": true, "table": true, "then": true,
+	"to": true, "union": true, "unique": true, "user": true, "when": true, "where": true,
+}
+
+// CompileDDL renders the relational schema of the registry as SQL DDL to schema.sql
+func CompileDDL(config MorpheCompileConfig, r *registry.Registry, writer *MorpheWriter) error {
+	schema, err := CompileSchema(config, r)
+	if err != nil {
+		return err
+	}
+
+	content := generateDDLContent(schema, config.DDL.GetDialect())
+	return writer.WriteSQLSchema(content)
+}
+
+// generateDDLContent renders CREATE statements for the schema in the given dialect.
+// Tables are created in foreign key dependency order; foreign keys that close a
+// cycle are added afterwards with ALTER TABLE.
+func generateDDLContent(schema *formatdef.Schema, dialect string) []byte {
+	cb := formatdef.NewContentBuilder("    ")
+	cb.Line("-- Code generated by Morphe")
+	cb.Line("-- Dialect: %s", dialect)
+
+	// SQLite has no schemas, qualified names refer to attached databases there
+	if dialect != typemap.DialectSQLite {
+		for _, schemaName := range getSchemaNames(schema) {
+			cb.Line("")
+			cb.Line("CREATE SCHEMA IF NOT EXISTS %s;", quoteSQLIdentifier(schemaName, dialect))
+		}
+	}
+
+	// Only PostgreSQL has standalone enum types, the other dialects declare them inline
+	if dialect == typemap.DialectPostgreSQL {
+		for _, enum := range schema.Enums {
+			cb.Line("")
+			cb.Line("CREATE TYPE %s AS ENUM (%s);", quoteSQLIdentifier(enum.TypeName, dialect), quoteSQLStringList(enum.Members))
+		}
+	}
+
+	tables, deferred := orderTablesByDependency(schema.Tables)
+
+	// SQLite cannot add constraints to existing tables but accepts forward references
+	if dialect == typemap.DialectSQLite {
+		for _, fk := range deferred {
+			for i := range tables {
+				if tables[i].QualifiedName() == fk.Table.QualifiedName() {
+					tables[i].ForeignKeys = append(tables[i].ForeignKeys, fk.ForeignKey)
+				}
+			}
+		}
+		deferred = nil
+	}
+
+	for _, table := range tables {
+		cb.Line("")
+		renderDDLTable(cb, table, schema, dialect)
+	}
+
+	for _, table := range tables {
+		for _, index := range table.Indexes {
+			cb.Line("")
+			cb.Line("CREATE INDEX %s ON %s (%s);",
+				quoteSQLIdentifier(index.Name, dialect),
+				quoteSQLTableName(table.Schema, table.Name, dialect),
+				quoteSQLIdentifierList(index.Columns, dialect))
+		}
+	}
+
+	for _, fk := range deferred {
+		cb.Line("")
+		cb.Line("ALTER TABLE %s ADD %s;", quoteSQLTableName(fk.Table.Schema, fk.Table.Name, dialect), renderDDLForeignKey(fk.ForeignKey, dialect))
+	}
+
+	// Views select from the tables, so they come last
+	for _, view := range schema.Views {
+		cb.Line("")
+		cb.Line("%s;", renderViewSQL(view, dialect))
+	}
+
+	return cb.Build()
+}
+
+// renderViewSQL renders the CREATE VIEW statement of a view, without a terminating semicolon
+func renderViewSQL(view formatdef.View, dialect string) string {
+	cb := formatdef.NewContentBuilder("    ")
+	cb.Line("CREATE VIEW %s AS", quoteSQLTableName(view.Schema, view.Name, dialect))
+	cb.Line("SELECT")
+	cb.Indent()
+	for i, column := range view.Columns {
+		selected := quoteSQLIdentifier(column.Source, dialect) + "." + quoteSQLIdentifier(column.SourceColumn, dialect)
+		if column.SourceColumn != column.Name {
+			selected += " AS " + quoteSQLIdentifier(column.Name, dialect)
+		}
+		if i < len(view.Columns)-1 {
+			selected += ","
+		}
+		cb.Line("%s", selected)
+	}
+	cb.Dedent()
+	cb.Line("FROM %s", renderViewTableSQL(view.FromSchema, view.From, view.From, dialect))
+	for _, join := range view.Joins {
+		keyword := "JOIN"
+		if join.Outer {
+			keyword = "LEFT OUTER JOIN"
+		}
+		cb.Line("%s %s ON %s.%s = %s.%s", keyword, renderViewTableSQL(join.Schema, join.Table, join.Alias, dialect),
+			quoteSQLIdentifier(join.Alias, dialect), quoteSQLIdentifier(join.Column, dialect),
+			quoteSQLIdentifier(join.Source, dialect), quoteSQLIdentifier(join.SourceColumn, dialect))
+	}
+	return strings.TrimSuffix(cb.String(), "\n")
+}
+
+// renderViewTableSQL renders a table a view selects from, aliased unless it is referred to by its own name
+func renderViewTableSQL(schemaName string, tableName string, alias string, dialect string) string {
+	rendered := quoteSQLTableName(schemaName, tableName, dialect)
+	if schemaName != "" || alias != tableName {
+		rendered += " AS " + quoteSQLIdentifier(alias, dialect)
+	}
+	return rendered
+}
+
+// renderDDLTable renders the CREATE TABLE statement of a table
+func renderDDLTable(cb *formatdef.ContentBuilder, table formatdef.Table, schema *formatdef.Schema, dialect string) {
+	var definitions []string
+	var primaryKeys []string
+	for _, column := range table.Columns {
+		definitions = append(definitions, renderDDLColumn(column, schema, dialect))
+		if column.PrimaryKey {
+			primaryKeys = append(primaryKeys, column.Name)
+		}
+	}
+	if len(primaryKeys) > 0 {
+		primaryKey := fmt.Sprintf("PRIMARY KEY (%s)", quoteSQLIdentifierList(primaryKeys, dialect))
+		if table.PrimaryKeyName != "" {
+			primaryKey = fmt.Sprintf("CONSTRAINT %s %s", quoteSQLIdentifier(table.PrimaryKeyName, dialect), primaryKey)
+		}
+		definitions = append(definitions, primaryKey)
+	}
+	for _, unique := range table.Uniques {
+		definitions = append(definitions, fmt.Sprintf("CONSTRAINT %s UNIQUE (%s)",
+			quoteSQLIdentifier(unique.Name, dialect), quoteSQLIdentifierList(unique.Columns, dialect)))
+	}
+	for _, fk := range table.ForeignKeys {
+		definitions = append(definitions, renderDDLForeignKey(fk, dialect))
+	}
+
+	tableName := quoteSQLTableName(table.Schema, table.Name, dialect)
+	if table.Comment != "" && dialect == typemap.DialectSQLite {
+		// SQLite does not store table comments
+		cb.Line("-- %s", strings.ReplaceAll(table.Comment, "\n", " "))
+	}
+	cb.Line("CREATE TABLE %s (", tableName)
+	cb.Indent()
+	for i, definition := range definitions {
+		if i < len(definitions)-1 {
+			definition += ","
+		}
+		cb.Line("%s", definition)
+	}
+	cb.Dedent()
+
+	switch {
+	case table.Comment != "" && dialect == typemap.DialectMySQL:
+		cb.Line(") COMMENT = %s;", quoteSQLStringList([]string{table.Comment}))
+	case table.Comment != "" && dialect == typemap.DialectPostgreSQL:
+		cb.Line(");")
+		cb.Line("")
+		cb.Line("COMMENT ON TABLE %s IS %s;", tableName, quoteSQLStringList([]string{table.Comment}))
+	default:
+		cb.Line(");")
+	}
+}
+
+// renderDDLColumn renders a column definition inside CREATE TABLE
+func renderDDLColumn(column formatdef.Column, schema *formatdef.Schema, dialect string) string {
+	sqlType := renderDDLType(column, schema, dialect)
+	if column.AutoIncrement && dialect == typemap.DialectPostgreSQL {
+		sqlType = "SERIAL"
+	}
+
+	definition := quoteSQLIdentifier(column.Name, dialect) + " " + sqlType
+	if !column.Nullable {
+		definition += " NOT NULL"
+	}
+	if column.AutoIncrement && dialect == typemap.DialectMySQL {
+		definition += " AUTO_INCREMENT"
+	}
+	return definition
+}
+
+// renderDDLType renders the SQL type of a column in the given dialect
+func renderDDLType(column formatdef.Column, schema *formatdef.Schema, dialect string) string {
+	if column.EnumName == "" {
+		return typemap.GetSQLType(dialect, column.Type)
+	}
+
+	enum, exists := schema.GetEnum(column.EnumName)
+	switch {
+	case !exists && dialect == typemap.DialectPostgreSQL:
+		return quoteSQLIdentifier(strings.ToLower(column.EnumName), dialect)
+	case dialect == typemap.DialectPostgreSQL:
+		return quoteSQLIdentifier(enum.TypeName, dialect)
+	case !exists:
+		return typemap.GetSQLType(dialect, "String")
+	case dialect == typemap.DialectMySQL:
+		return fmt.Sprintf("ENUM(%s)", quoteSQLStringList(enum.Members))
+	}
+
+	// Non-native enums are stored as strings sized to the longest member
+	length := 0
+	for _, member := range enum.Members {
+		if len(member) > length {
+			length = len(member)
+		}
+	}
+	return fmt.Sprintf("VARCHAR(%d)", length)
+}
+
+// renderDDLForeignKey renders a named foreign key constraint
+func renderDDLForeignKey(fk formatdef.ForeignKey, dialect string) string {
+	return fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		quoteSQLIdentifier(fk.Name, dialect),
+		quoteSQLIdentifier(fk.Column, dialect),
+		quoteSQLTableName(fk.RefSchema, fk.RefTable, dialect),
+		quoteSQLIdentifier(fk.RefColumn, dialect))
+}
+
+// quoteSQLIdentifier quotes an identifier if it is reserved or not plain lower case
+func quoteSQLIdentifier(name string, dialect string) string {
+	needsQuoting := sqlReservedWords[name] || name == ""
+	for i, r := range name {
+		isPlain := ('a' <= r && r <= 'z') || r == '_' || (i > 0 && '0' <= r && r <= '9')
+		if !isPlain {
+			needsQuoting = true
+			break
+		}
+	}
+	if !needsQuoting {
+		return name
+	}
+	if dialect == typemap.DialectMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteSQLTableName quotes a table name, qualified with its schema if any
+func quoteSQLTableName(schemaName string, tableName string, dialect string) string {
+	if schemaName == "" {
+		return quoteSQLIdentifier(tableName, dialect)
+	}
+	return quoteSQLIdentifier(schemaName, dialect) + "." + quoteSQLIdentifier(tableName, dialect)
+}
+
+// getSchemaNames returns the sorted database schemas used by the tables
+func getSchemaNames(schema *formatdef.Schema) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, table := range schema.Tables {
+		if table.Schema != "" && !seen[table.Schema] {
+			seen[table.Schema] = true
+			names = append(names, table.Schema)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// quoteSQLIdentifierList renders a comma separated list of identifiers
+func quoteSQLIdentifierList(names []string, dialect string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, quoteSQLIdentifier(name, dialect))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quoteSQLStringList renders a comma separated list of string literals
+func quoteSQLStringList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, "'"+strings.ReplaceAll(value, "'", "''")+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,138 @@
+package compile_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/internal/testutils"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+)
+
+type CompileDDLTestSuite struct {
+	suite.Suite
+
+	TestDirPath string
+}
+
+func TestCompileDDLTestSuite(t *testing.T) {
+	suite.Run(t, new(CompileDDLTestSuite))
+}
+
+func (suite *CompileDDLTestSuite) SetupTest() {
+	suite.TestDirPath = testutils.GetTestDirPath()
+}
+
+// compileDDL compiles the named registry with DDL output in the dialect and returns schema.sql
+func (suite *CompileDDLTestSuite) compileDDL(registryName string, dialect string, formatConfig compile.SQLAlchemyConfig) string {
+	formatConfig.UseDeclarative = true
+	formatConfig.AddTypeHints = true
+	formatConfig.IndentSize = 4
+	formatConfig.PythonVersion = "3.8"
+
+	registryDirPath := filepath.Join(suite.TestDirPath, "registry", registryName)
+	outputPath := suite.T().TempDir()
+	suite.Require().NoError(compile.MorpheToSQLAlchemy(compile.MorpheCompileConfig{
+		MorpheLoadRegistryConfig: rcfg.MorpheLoadRegistryConfig{
+			RegistryEnumsDirPath:      filepath.Join(registryDirPath, "enums"),
+			RegistryStructuresDirPath: filepath.Join(registryDirPath, "structures"),
+			RegistryModelsDirPath:     filepath.Join(registryDirPath, "models"),
+			RegistryEntitiesDirPath:   filepath.Join(registryDirPath, "entities"),
+		},
+		OutputPath:   outputPath,
+		FormatConfig: formatConfig,
+		DDL:          cfg.DDLConfig{Enabled: true, Dialect: dialect},
+	}))
+
+	content, err := os.ReadFile(filepath.Join(outputPath, "schema.sql"))
+	suite.Require().NoError(err)
+	return string(content)
+}
+
+func (suite *CompileDDLTestSuite) TestCompileDDL_PostgreSQL() {
+	schema := suite.compileDDL("minimal", "", compile.SQLAlchemyConfig{})
+
+	groundTruth, err := os.ReadFile(filepath.Join(suite.TestDirPath, "ground-truth", "compile-minimal", "schema.sql"))
+	suite.Require().NoError(err)
+	suite.Equal(string(groundTruth), schema)
+}
+
+func (suite *CompileDDLTestSuite) TestCompileDDL_MySQL() {
+	schema := suite.compileDDL("minimal", "mysql", compile.SQLAlchemyConfig{})
+
+	suite.Contains(schema, "-- Dialect: mysql")
+	suite.Contains(schema, "    id INTEGER NOT NULL AUTO_INCREMENT,\n")
+	suite.Contains(schema, "    name VARCHAR(255) NOT NULL,\n")
+	// Enums are declared inline instead of as types
+	suite.Contains(schema, "    nationality ENUM('DE', 'FR', 'US') NOT NULL,\n")
+	suite.NotContains(schema, "CREATE TYPE")
+	suite.NotContains(schema, "SERIAL")
+	suite.Contains(schema, "CONSTRAINT fk_person_company_id_company FOREIGN KEY (company_id) REFERENCES company (id)")
+	suite.Contains(schema, "CREATE INDEX ix_person_company_id ON person (company_id);")
+}
+
+func (suite *CompileDDLTestSuite) TestCompileDDL_MySQLSchemasAndComments() {
+	schema := suite.compileDDL("minimal", "mysql", compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"Person": {Name: "Person", Schema: "crm", Comment: "Contact persons"},
+		},
+	})
+
+	suite.Contains(schema, "CREATE SCHEMA IF NOT EXISTS crm;")
+	// Identifiers that aren't plain lower case are quoted with backticks
+	suite.Contains(schema, "CREATE TABLE crm.`Person` (")
+	suite.Contains(schema, ") COMMENT = 'Contact persons';")
+	suite.Contains(schema, "REFERENCES crm.`Person` (id)")
+}
+
+func (suite *CompileDDLTestSuite) TestCompileDDL_SQLite() {
+	schema := suite.compileDDL("minimal", "sqlite", compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"Company": {Comment: "Employers"},
+		},
+	})
+
+	suite.Contains(schema, "-- Dialect: sqlite")
+	// SQLite has no auto increment keyword, INTEGER primary keys are row ids
+	suite.Contains(schema, "    id INTEGER NOT NULL,\n")
+	suite.NotContains(schema, "AUTO_INCREMENT")
+	suite.NotContains(schema, "SERIAL")
+	// Enums are stored as strings sized to the longest member
+	suite.Contains(schema, "    nationality VARCHAR(2) NOT NULL,\n")
+	suite.NotContains(schema, "CREATE TYPE")
+	// Table comments are kept as SQL comments
+	suite.Contains(schema, "-- Employers\nCREATE TABLE company (")
+	suite.NotContains(schema, "COMMENT")
+}
+
+func (suite *CompileDDLTestSuite) TestCompileDDL_CyclicForeignKeys() {
+	schema := suite.compileDDL("cyclic", "", compile.SQLAlchemyConfig{})
+
+	// The foreign key closing the cycle is added once both tables exist
+	employee := "CREATE TABLE employee (\n    id SERIAL NOT NULL,\n    name VARCHAR NOT NULL,\n    department_id INTEGER NOT NULL,\n    CONSTRAINT pk_employee PRIMARY KEY (id)\n);"
+	suite.Contains(schema, employee)
+	suite.Contains(schema, "CONSTRAINT fk_department_employee_id_employee FOREIGN KEY (employee_id) REFERENCES employee (id)\n);")
+	suite.Less(strings.Index(schema, "CREATE TABLE employee"), strings.Index(schema, "CREATE TABLE department"))
+	alter := "ALTER TABLE employee ADD CONSTRAINT fk_employee_department_id_department FOREIGN KEY (department_id) REFERENCES department (id);"
+	suite.Contains(schema, alter)
+	suite.Less(strings.Index(schema, "CREATE INDEX"), strings.Index(schema, alter))
+}
+
+func (suite *CompileDDLTestSuite) TestCompileDDL_CyclicForeignKeysSQLite() {
+	schema := suite.compileDDL("cyclic", "sqlite", compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"Department": {Name: "employee", Schema: "hr"},
+		},
+	})
+
+	// SQLite can't alter constraints, so the deferred foreign key is declared inline on its own table only
+	suite.NotContains(schema, "ALTER TABLE")
+	suite.Equal(1, strings.Count(schema, "CONSTRAINT fk_employee_employee_id_employee FOREIGN KEY (employee_id) REFERENCES employee (id)"))
+	suite.Contains(schema, "CREATE TABLE hr.employee (\n    id INTEGER NOT NULL,\n    name VARCHAR NOT NULL,\n    employee_id INTEGER NOT NULL,\n    CONSTRAINT pk_employee PRIMARY KEY (id),\n    CONSTRAINT fk_employee_employee_id_employee")
+	suite.Contains(schema, "CREATE TABLE employee (\n    id INTEGER NOT NULL,\n    name VARCHAR NOT NULL,\n    department_id INTEGER NOT NULL,\n    CONSTRAINT pk_employee PRIMARY KEY (id),\n    CONSTRAINT fk_employee_department_id_employee FOREIGN KEY (department_id) REFERENCES hr.employee (id)\n);")
+}
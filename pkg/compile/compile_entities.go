@@ -8,12 +8,13 @@ import (
 	"github.com/kalo-build/morphe-go/pkg/registry"
 	"github.com/kalo-build/morphe-go/pkg/yaml"
 	"github.com/kalo-build/morphe-go/pkg/yamlops"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
 	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
 	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/typemap"
 )
 
 // CompileEntity converts a Morphe entity to the target format
-func CompileEntity(entity yaml.Entity, r *registry.Registry) (*formatdef.Struct, error) {
+func CompileEntity(entity yaml.Entity, config SQLAlchemyConfig, r *registry.Registry) (*formatdef.Struct, error) {
 	// Create the struct definition
 	formatStruct := &formatdef.Struct{
 		Name:   entity.Name,
@@ -81,44 +82,17 @@ func CompileEntity(entity yaml.Entity, r *registry.Registry) (*formatdef.Struct,
 				formatStruct.Fields = append(formatStruct.Fields, fkField)
 			}
 
-			// Add navigation field based on relation type
-			// Resolve the actual target using aliasing
-			targetName := yamlops.GetRelationTargetName(relatedName, relation.Aliased)
-
-			var navType formatdef.Type
+			// Add navigation field, typed by the related entities or models
+			navType := formatdef.Type(formatdef.BasicType{Name: getEntityRelationType(relatedName, relation, r)})
 			var navFieldName string
 
-			if yamlops.IsRelationPoly(relationType) {
-				// Polymorphic relationships need Union types
-				if len(relation.For) > 0 {
-					// Create a custom type representing the Union
-					unionType := "Union["
-					for i, forModel := range relation.For {
-						if i > 0 {
-							unionType += ", "
-						}
-						unionType += "'" + forModel + "'"
-					}
-					unionType += "]"
-					navType = formatdef.BasicType{Name: unionType}
-				} else {
-					// No 'for' specified, use Any
-					navType = formatdef.TypeAny
-				}
-			} else {
-				// Regular relationship - use the aliased target
-				navType = formatdef.BasicType{Name: targetName}
-			}
-
 			// Determine if it's a collection
 			if yamlops.IsRelationMany(relationType) {
-				if _, ok := navType.(formatdef.BasicType); ok {
-					// Wrap in List for many relationships
-					navType = formatdef.ArrayType{ElementType: navType}
-				}
-				// Pluralize for many relationships
-				navFieldName = formatdef.ToSnakeCase(relatedName) + "s"
+				// Wrap in List for many relationships
+				navType = formatdef.ArrayType{ElementType: navType}
+				navFieldName = getCollectionName(relatedName, config)
 			} else {
+				navType = formatdef.BasicType{Name: "Optional[" + navType.GetName() + "]", Nullable: true}
 				navFieldName = formatdef.ToSnakeCase(relatedName)
 			}
 
@@ -133,47 +107,428 @@ func CompileEntity(entity yaml.Entity, r *registry.Registry) (*formatdef.Struct,
 	return formatStruct, nil
 }
 
+// getEntityRelationTarget resolves the related entity of a relation target, or its model if there is no such entity.
+// Entity files import both only for type checking, so the name is returned quoted as a forward reference.
+func getEntityRelationTarget(targetName string, r *registry.Registry) (string, bool) {
+	if _, err := r.GetEntity(targetName); err == nil {
+		return "'" + targetName + "'", true
+	}
+	return "'models." + targetName + "'", false
+}
+
+// getEntityRelationType returns the type of a single related item, a Union over the for targets of polymorphic relations
+func getEntityRelationType(relatedName string, relation yaml.EntityRelation, r *registry.Registry) string {
+	if !yamlops.IsRelationPoly(string(relation.Type)) {
+		target, _ := getEntityRelationTarget(yamlops.GetRelationTargetName(relatedName, relation.Aliased), r)
+		return target
+	}
+	if len(relation.For) == 0 {
+		return "Any"
+	}
+	var targets []string
+	for _, forName := range relation.For {
+		target, _ := getEntityRelationTarget(forName, r)
+		targets = append(targets, target)
+	}
+	if len(targets) == 1 {
+		return targets[0]
+	}
+	return "Union[" + strings.Join(targets, ", ") + "]"
+}
+
 // resolveEntityFieldType resolves a model field path to a concrete type
 func resolveEntityFieldType(fieldPath yaml.ModelFieldPath, r *registry.Registry) (formatdef.Type, error) {
-	// Split the path (e.g., "User.email" or "User.ContactInfo.email")
+	_, terminalModel, fieldName, err := walkEntityFieldPath(fieldPath, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the appropriate type
+	return typemap.GetFieldType(terminalModel.Fields[fieldName].Type), nil
+}
+
+// walkEntityFieldPath follows a model field path (e.g. "User.email" or "User.ContactInfo.email")
+// and returns the relations it passes through, the model owning the terminal field and that field's name
+func walkEntityFieldPath(fieldPath yaml.ModelFieldPath, r *registry.Registry) ([]entityPathHop, yaml.Model, string, error) {
 	parts := strings.Split(string(fieldPath), ".")
 	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid field path: %s", fieldPath)
+		return nil, yaml.Model{}, "", fmt.Errorf("invalid field path: %s", fieldPath)
 	}
 
 	// Get the root model
 	currentModel, err := r.GetModel(parts[0])
 	if err != nil {
-		return nil, fmt.Errorf("model %s not found", parts[0])
+		return nil, yaml.Model{}, "", fmt.Errorf("model %s not found", parts[0])
 	}
 
 	// Navigate through the path
+	var hops []entityPathHop
 	for i := 1; i < len(parts)-1; i++ {
 		// This is a related model
 		relation, exists := currentModel.Related[parts[i]]
 		if !exists {
-			return nil, fmt.Errorf("relation %s not found in model %s", parts[i], currentModel.Name)
+			return nil, yaml.Model{}, "", fmt.Errorf("relation %s not found in model %s", parts[i], currentModel.Name)
 		}
-
 		// Resolve the actual target model name using aliasing
 		targetModelName := yamlops.GetRelationTargetName(parts[i], relation.Aliased)
+		hops = append(hops, entityPathHop{RelationName: parts[i], RelationType: string(relation.Type), TargetModel: targetModelName})
 
 		// Get the related model using the resolved target name
 		currentModel, err = r.GetModel(targetModelName)
 		if err != nil {
-			return nil, fmt.Errorf("related model %s not found", targetModelName)
+			return nil, yaml.Model{}, "", fmt.Errorf("related model %s not found", targetModelName)
 		}
 	}
 
 	// Get the terminal field
 	fieldName := parts[len(parts)-1]
-	field, exists := currentModel.Fields[fieldName]
-	if !exists {
-		return nil, fmt.Errorf("field %s not found in model %s", fieldName, currentModel.Name)
+	if _, exists := currentModel.Fields[fieldName]; !exists {
+		return nil, yaml.Model{}, "", fmt.Errorf("field %s not found in model %s", fieldName, currentModel.Name)
 	}
+	return hops, currentModel, fieldName, nil
+}
 
-	// Return the appropriate type
-	return typemap.GetFieldType(field.Type), nil
+// entityPathHop is a relation traversed by an entity field path
+type entityPathHop struct {
+	RelationName string
+	RelationType string
+	TargetModel  string
+}
+
+// entityAssignment sets one entity attribute from a Python expression over the root model
+type entityAssignment struct {
+	Attribute  string
+	Expression string
+	// Column expression selected for the attribute by select(), or "" if it isn't read from a column
+	Column string
+}
+
+// entityJoin joins an aliased related model along a relationship in the entity's select()
+type entityJoin struct {
+	Alias        string
+	Model        string
+	Relationship string
+	Outer        bool
+}
+
+// entityMapping describes how an entity is populated from an instance of its root model
+type entityMapping struct {
+	RootModel   string
+	Parameter   string
+	Assignments []entityAssignment
+	Joins       []entityJoin
+}
+
+// compileEntityMapping resolves the model attribute chains an entity's from_model() reads
+func compileEntityMapping(entity yaml.Entity, config SQLAlchemyConfig, r *registry.Registry) (*entityMapping, error) {
+	var fieldNames []string
+	for name := range entity.Fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+	if len(fieldNames) == 0 {
+		return nil, nil
+	}
+
+	mapping := &entityMapping{}
+	for _, fieldName := range fieldNames {
+		fieldPath := entity.Fields[fieldName].Type
+		rootModel := strings.Split(string(fieldPath), ".")[0]
+		if mapping.RootModel == "" {
+			mapping.RootModel = rootModel
+			mapping.Parameter = SanitizePythonIdentifier(formatdef.ToSnakeCase(rootModel))
+		} else if rootModel != mapping.RootModel {
+			return nil, fmt.Errorf("field %s reads model %s, but the entity is based on model %s", fieldName, rootModel, mapping.RootModel)
+		}
+
+		hops, _, terminalField, err := walkEntityFieldPath(fieldPath, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve field path for %s: %w", fieldName, err)
+		}
+
+		// Every hop may be None, so the value is only read when the whole chain exists
+		access := mapping.Parameter
+		var guards []string
+		// The select() joins one alias per relation path, outer joined from the first optional hop on
+		source := "models." + rootModel
+		var aliasParts []string
+		outer := false
+		for _, hop := range hops {
+			if yamlops.IsRelationMany(hop.RelationType) || yamlops.IsRelationPoly(hop.RelationType) {
+				return nil, fmt.Errorf("field path %s of %s crosses %s relation %s", fieldPath, fieldName, hop.RelationType, hop.RelationName)
+			}
+			relationAttribute := SanitizePythonIdentifier(formatdef.ToSnakeCase(hop.RelationName))
+			access += "." + relationAttribute
+			guards = append(guards, access+" is not None")
+
+			// ForOne foreign key columns are not nullable, any other relation may be missing
+			outer = outer || !yamlops.IsRelationFor(hop.RelationType)
+			aliasParts = append(aliasParts, relationAttribute)
+			alias := SanitizePythonIdentifier(strings.Join(aliasParts, "_"))
+			if !mapping.hasJoin(alias) {
+				mapping.Joins = append(mapping.Joins, entityJoin{
+					Alias:        alias,
+					Model:        hop.TargetModel,
+					Relationship: source + "." + relationAttribute,
+					Outer:        outer,
+				})
+			}
+			source = alias
+		}
+		expression := access + "." + getAttributeName(terminalField)
+		if len(guards) > 0 {
+			expression = fmt.Sprintf("%s if %s else None", expression, strings.Join(guards, " and "))
+		}
+
+		mapping.Assignments = append(mapping.Assignments, entityAssignment{
+			Attribute:  SanitizePythonIdentifier(formatdef.ToSnakeCase(fieldName)),
+			Expression: expression,
+			Column:     source + "." + getAttributeName(terminalField),
+		})
+	}
+
+	rootModel, err := r.GetModel(mapping.RootModel)
+	if err != nil {
+		return nil, ErrModelNotFound(mapping.RootModel)
+	}
+
+	var relatedNames []string
+	for name := range entity.Related {
+		relatedNames = append(relatedNames, name)
+	}
+	sort.Strings(relatedNames)
+
+	for _, relatedName := range relatedNames {
+		relationType := string(entity.Related[relatedName].Type)
+
+		// Key columns are copied when the root model has the same relation
+		var keySuffixes []string
+		if yamlops.IsRelationPoly(relationType) && yamlops.IsRelationFor(relationType) && yamlops.IsRelationOne(relationType) {
+			keySuffixes = []string{"_type", "_id"}
+		} else if !yamlops.IsRelationPoly(relationType) && yamlops.IsRelationFor(relationType) && yamlops.IsRelationOne(relationType) {
+			keySuffixes = []string{"_id"}
+		}
+		modelRelation, hasModelRelation := rootModel.Related[relatedName]
+		for _, suffix := range keySuffixes {
+			assignment := entityAssignment{
+				Attribute:  formatdef.ToSnakeCase(relatedName + suffix),
+				Expression: "None",
+			}
+			if hasModelRelation && modelRelation.Type == entity.Related[relatedName].Type {
+				assignment.Expression = mapping.Parameter + "." + getAttributeName(relatedName+suffix)
+				assignment.Column = "models." + mapping.RootModel + "." + getAttributeName(relatedName+suffix)
+			}
+			mapping.Assignments = append(mapping.Assignments, assignment)
+		}
+
+		// Related entities are populated by their loaders, not by following model relationships
+		if yamlops.IsRelationMany(relationType) {
+			mapping.Assignments = append(mapping.Assignments, entityAssignment{
+				Attribute:  getCollectionName(relatedName, config),
+				Expression: "[]",
+			})
+		} else {
+			mapping.Assignments = append(mapping.Assignments, entityAssignment{
+				Attribute:  formatdef.ToSnakeCase(relatedName),
+				Expression: "None",
+			})
+		}
+	}
+
+	return mapping, nil
+}
+
+// hasJoin reports whether the select() already joins an alias
+func (mapping *entityMapping) hasJoin(alias string) bool {
+	for _, join := range mapping.Joins {
+		if join.Alias == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// entityLoader loads the entities of a relation through the root model's relationship
+type entityLoader struct {
+	// Attribute is the navigation attribute the loader fills
+	Attribute    string
+	TargetEntity string
+	// TargetType annotates a loaded item, with forward references to the related entities or models
+	TargetType string
+	Many       bool
+	// Unsupported explains why the relation can't be loaded, or is "" if it can
+	Unsupported  string
+	SourceModel  string
+	TargetModel  string
+	Relationship string
+	// SourceKeys match the root model's primary key columns to the entity's attributes
+	SourceKeys []entityLoaderKey
+	// TargetKeys are the primary key attributes of the target model
+	TargetKeys []string
+}
+
+// entityLoaderKey pairs a primary key column of the root model with the entity attribute holding it
+type entityLoaderKey struct {
+	Column    string
+	Attribute string
+}
+
+// compileEntityLoaders resolves how each related entity is queried from the root model
+func compileEntityLoaders(entity yaml.Entity, mapping *entityMapping, config SQLAlchemyConfig, r *registry.Registry) ([]entityLoader, error) {
+	var relatedNames []string
+	for name := range entity.Related {
+		relatedNames = append(relatedNames, name)
+	}
+	sort.Strings(relatedNames)
+
+	var loaders []entityLoader
+	for _, relatedName := range relatedNames {
+		relation := entity.Related[relatedName]
+		relationType := string(relation.Type)
+
+		loader := entityLoader{
+			Attribute:    formatdef.ToSnakeCase(relatedName),
+			TargetEntity: yamlops.GetRelationTargetName(relatedName, relation.Aliased),
+			TargetType:   getEntityRelationType(relatedName, relation, r),
+			Many:         yamlops.IsRelationMany(relationType),
+		}
+		if loader.Many {
+			loader.Attribute = getCollectionName(relatedName, config)
+		}
+
+		unsupported, err := resolveEntityLoader(&loader, entity, relatedName, mapping, config, r)
+		if err != nil {
+			return nil, err
+		}
+		loader.Unsupported = unsupported
+		loaders = append(loaders, loader)
+	}
+	return loaders, nil
+}
+
+// resolveEntityLoader fills in the query of a loader, returning why it can't be loaded if it can't
+func resolveEntityLoader(loader *entityLoader, entity yaml.Entity, relatedName string, mapping *entityMapping, config SQLAlchemyConfig, r *registry.Registry) (string, error) {
+	relation := entity.Related[relatedName]
+	if yamlops.IsRelationPoly(string(relation.Type)) {
+		loader.TargetEntity = ""
+		return fmt.Sprintf("polymorphic relation %s can't be loaded", relatedName), nil
+	}
+	if mapping == nil {
+		return fmt.Sprintf("entity %s has no fields to load %s from", entity.Name, relatedName), nil
+	}
+
+	rootModel, err := r.GetModel(mapping.RootModel)
+	if err != nil {
+		return "", ErrModelNotFound(mapping.RootModel)
+	}
+	modelRelation, hasModelRelation := rootModel.Related[relatedName]
+	if !hasModelRelation || modelRelation.Type != relation.Type {
+		return fmt.Sprintf("model %s has no %s relation %s", mapping.RootModel, relation.Type, relatedName), nil
+	}
+	loader.SourceModel = mapping.RootModel
+	loader.TargetModel = yamlops.GetRelationTargetName(relatedName, modelRelation.Aliased)
+	loader.Relationship = "models." + mapping.RootModel + "." + getRelationshipName(relatedName, modelRelation.Type, config)
+
+	targetEntity, err := r.GetEntity(loader.TargetEntity)
+	if err != nil {
+		return fmt.Sprintf("there is no %s entity to load", loader.TargetEntity), nil
+	}
+	targetMapping, err := compileEntityMapping(targetEntity, config, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to compile model mapping for entity %s: %w", loader.TargetEntity, err)
+	}
+	if targetMapping == nil || targetMapping.RootModel != loader.TargetModel {
+		return fmt.Sprintf("entity %s isn't based on model %s", loader.TargetEntity, loader.TargetModel), nil
+	}
+
+	// The entity's primary identifier selects the root model row the relation starts from
+	for _, fieldName := range entity.Identifiers["primary"].Fields {
+		parts := strings.Split(string(entity.Fields[fieldName].Type), ".")
+		if len(parts) != 2 {
+			return fmt.Sprintf("primary identifier field %s of %s isn't a column of model %s", fieldName, entity.Name, mapping.RootModel), nil
+		}
+		loader.SourceKeys = append(loader.SourceKeys, entityLoaderKey{
+			Column:    "models." + mapping.RootModel + "." + getAttributeName(parts[1]),
+			Attribute: SanitizePythonIdentifier(formatdef.ToSnakeCase(fieldName)),
+		})
+	}
+	if len(loader.SourceKeys) == 0 {
+		return fmt.Sprintf("entity %s has no primary identifier", entity.Name), nil
+	}
+
+	targetModel, err := r.GetModel(loader.TargetModel)
+	if err != nil {
+		return "", ErrModelNotFound(loader.TargetModel)
+	}
+	for _, fieldName := range targetModel.Identifiers["primary"].Fields {
+		loader.TargetKeys = append(loader.TargetKeys, getAttributeName(fieldName))
+	}
+	if len(loader.TargetKeys) == 0 {
+		return fmt.Sprintf("model %s has no primary identifier", loader.TargetModel), nil
+	}
+	return "", nil
+}
+
+// entityImmutability describes how an entity's immutable fields are enforced
+type entityImmutability struct {
+	// Frozen is set when every field is immutable and the entity is a frozen dataclass
+	Frozen bool
+	// ReadOnly fields are properties over private slots on plain classes
+	ReadOnly map[string]bool
+	// WriteOnce fields of dataclasses can't change once they are set
+	WriteOnce map[string]bool
+	// attributes are the Python attribute names of the ReadOnly fields
+	attributes map[string]bool
+}
+
+// getEntityImmutability collects the immutable fields of an entity
+func getEntityImmutability(entity yaml.Entity, config SQLAlchemyConfig) entityImmutability {
+	immutability := entityImmutability{
+		ReadOnly:   make(map[string]bool),
+		WriteOnce:  make(map[string]bool),
+		attributes: make(map[string]bool),
+	}
+	var immutableNames []string
+	for fieldName, field := range entity.Fields {
+		if containsString(field.Attributes, "immutable") {
+			immutableNames = append(immutableNames, fieldName)
+		}
+	}
+	if len(immutableNames) == 0 {
+		return immutability
+	}
+	if len(immutableNames) == len(entity.Fields) {
+		immutability.Frozen = true
+		return immutability
+	}
+	for _, fieldName := range immutableNames {
+		if config.UseDataclass {
+			immutability.WriteOnce[fieldName] = true
+		} else {
+			immutability.ReadOnly[fieldName] = true
+			immutability.attributes[SanitizePythonIdentifier(formatdef.ToSnakeCase(fieldName))] = true
+		}
+	}
+	return immutability
+}
+
+// renderAssignment renders setting an attribute of a new entity, bypassing read-only properties and frozen dataclasses
+func (immutability entityImmutability) renderAssignment(attribute string, expression string) string {
+	if immutability.Frozen {
+		return fmt.Sprintf("object.__setattr__(entity, '%s', %s)", attribute, expression)
+	}
+	if immutability.attributes[attribute] {
+		return fmt.Sprintf("entity._%s = %s", attribute, expression)
+	}
+	return fmt.Sprintf("entity.%s = %s", attribute, expression)
+}
+
+// getLazyLoadingStyle returns the configured lazy loading style, async unless set
+func getLazyLoadingStyle(entityConfig cfg.EntityConfig) string {
+	if entityConfig.LazyLoadingStyle == "" {
+		return "async"
+	}
+	return entityConfig.LazyLoadingStyle
 }
 
 // resolveFieldType checks if a type name is an enum, model, or basic type
@@ -186,6 +541,10 @@ func resolveFieldType(typeName string, r *registry.Registry) string {
 	if _, err := r.GetModel(typeName); err == nil {
 		return "model"
 	}
+	// Check if it's a structure
+	if _, err := r.GetStructure(typeName); err == nil {
+		return "structure"
+	}
 	// Otherwise it's a basic type or unknown
 	return "basic"
 }
@@ -225,35 +584,149 @@ func CompileAllEntities(config MorpheCompileConfig, r *registry.Registry, writer
 	// Process each entity in the registry
 	for entityName, entity := range r.GetAllEntities() {
 		// Compile the entity
-		compiledEntity, err := CompileEntity(entity, r)
+		compiledEntity, err := CompileEntity(entity, config.FormatConfig, r)
 		if err != nil {
 			return fmt.Errorf("failed to compile entity %s: %w", entityName, err)
 		}
 
+		mapping, err := compileEntityMapping(entity, config.FormatConfig, r)
+		if err != nil {
+			return fmt.Errorf("failed to compile model mapping for entity %s: %w", entityName, err)
+		}
+
+		loaders, err := compileEntityLoaders(entity, mapping, config.FormatConfig, r)
+		if err != nil {
+			return fmt.Errorf("failed to compile loaders for entity %s: %w", entityName, err)
+		}
+
 		// Generate the content for this entity
-		content := generateEntityContent(compiledEntity, entity, config.FormatConfig, r)
+		content := generateEntityContent(compiledEntity, entity, mapping, loaders, config.FormatConfig, config.MorpheConfig.Entities, r)
 		entityContents[entityName] = content
 	}
 
 	// Write all entity contents
-	return writer.WriteAllEntities(entityContents)
+	if err := writer.WriteAllEntities(entityContents); err != nil {
+		return err
+	}
+	if config.MorpheConfig.Entities.IncludeValidation {
+		return writer.WriteEntityErrors(generateEntityErrorsContent())
+	}
+	return nil
+}
+
+// generateEntityErrorsContent generates the errors raised by entity validation
+func generateEntityErrorsContent() []byte {
+	cb := formatdef.NewContentBuilder("    ")
+	cb.Line("# Entity validation errors")
+	cb.Line("")
+	cb.Line("from typing import List")
+	cb.Line("")
+	cb.Line("")
+	cb.Line("class EntityValidationError(ValueError):")
+	cb.Indent()
+	cb.Line(`"""Raised with every violation found when validating an entity."""`)
+	cb.Line("")
+	cb.Line("def __init__(self, entity: str, violations: List[str]):")
+	cb.Indent()
+	cb.Line("self.entity = entity")
+	cb.Line("self.violations = violations")
+	cb.Line(`super().__init__(f"invalid {entity}: " + "; ".join(violations))`)
+	cb.Dedent()
+	cb.Dedent()
+	return cb.Build()
+}
+
+// renderEntityValidation renders validate() with the checks an entity's registry definition implies.
+// Dataclasses run it from __post_init__, and the from_model()/from_row() factories call it explicitly.
+func renderEntityValidation(cb *formatdef.ContentBuilder, entity *formatdef.Struct, morpheEntity yaml.Entity, config SQLAlchemyConfig, r *registry.Registry) {
+	identifierFields := make(map[string]string)
+	for idName, identifier := range morpheEntity.Identifiers {
+		for _, fieldName := range identifier.Fields {
+			identifierFields[fieldName] = idName
+		}
+	}
+	polyTypes := make(map[string][]string)
+	for relatedName, relation := range morpheEntity.Related {
+		relationType := string(relation.Type)
+		if yamlops.IsRelationPoly(relationType) && yamlops.IsRelationFor(relationType) && yamlops.IsRelationOne(relationType) && len(relation.For) > 0 {
+			polyTypes[formatdef.ToSnakeCase(relatedName+"_type")] = relation.For
+		}
+	}
+
+	if config.UseDataclass {
+		cb.Line("def __post_init__(self) -> None:")
+		cb.Indent()
+		cb.Line("self.validate()")
+		cb.Dedent()
+		cb.Line("")
+	}
+	cb.Line("def validate(self) -> None:")
+	cb.Indent()
+	cb.Line(`"""Check the %s against the registry, raising EntityValidationError with every violation."""`, entity.Name)
+	cb.Line("violations = []")
+	for _, field := range entity.Fields {
+		attribute := SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name))
+		value := fmt.Sprintf("getattr(self, '%s', None)", attribute)
+
+		if containsString(morpheEntity.Fields[field.Name].Attributes, "mandatory") {
+			cb.Line("if %s is None:", value)
+			cb.Indent()
+			cb.Line(`violations.append("%s is mandatory")`, attribute)
+			cb.Dedent()
+		} else if idName, isIdentifier := identifierFields[field.Name]; isIdentifier {
+			cb.Line("if %s is None:", value)
+			cb.Indent()
+			cb.Line(`violations.append("%s is required by the %s identifier")`, attribute, idName)
+			cb.Dedent()
+		}
+
+		if typeName := field.Type.GetName(); resolveFieldType(typeName, r) == "enum" {
+			cb.Line("if %s is not None and not isinstance(self.%s, %s):", value, attribute, typeName)
+			cb.Indent()
+			cb.Line(`violations.append(f"%s must be a %s member, got {self.%s!r}")`, attribute, typeName, attribute)
+			cb.Dedent()
+		}
+
+		if allowedTypes, isPolyType := polyTypes[field.Name]; isPolyType {
+			var quoted []string
+			for _, allowedType := range allowedTypes {
+				quoted = append(quoted, fmt.Sprintf("'%s'", allowedType))
+			}
+			allowed := strings.Join(quoted, ", ")
+			if len(quoted) == 1 {
+				allowed += ","
+			}
+			cb.Line("if %s is not None and self.%s not in (%s):", value, attribute, allowed)
+			cb.Indent()
+			cb.Line(`violations.append(f"%s must be one of %s, got {self.%s!r}")`, attribute, strings.Join(allowedTypes, ", "), attribute)
+			cb.Dedent()
+		}
+	}
+	cb.Line("if violations:")
+	cb.Indent()
+	cb.Line("raise EntityValidationError('%s', violations)", entity.Name)
+	cb.Dedent()
+	cb.Dedent()
 }
 
 // generateEntityContent generates Python entity with relationships and identifiers
-func generateEntityContent(entity *formatdef.Struct, morpheEntity yaml.Entity, config SQLAlchemyConfig, r *registry.Registry) []byte {
+func generateEntityContent(entity *formatdef.Struct, morpheEntity yaml.Entity, mapping *entityMapping, loaders []entityLoader, config SQLAlchemyConfig, entityConfig cfg.EntityConfig, r *registry.Registry) []byte {
 	cb := formatdef.NewContentBuilder("    ")
 
 	// Add header comment
-	cb.Line("# Code generated by Morphe")
 	cb.Line("# Entity DTO (Data Transfer Object)")
 	cb.Line("# Note: Entities are DTOs/ViewModels, not SQLAlchemy ORM models")
 	cb.Line("")
 
 	// Create import tracker
 	imports := NewImportTracker(r)
+	imports.ExcludeType(entity.Name)
+
+	// Immutable fields decide between a frozen dataclass, read-only properties and write-once fields
+	immutability := getEntityImmutability(morpheEntity, config)
 
 	// For entities, we'll use dataclasses only if configured
-	if config.UseDataclass {
+	if config.UseDataclass || immutability.Frozen {
 		imports.AddFrom("dataclasses", "dataclass")
 	}
 
@@ -277,6 +750,77 @@ func generateEntityContent(entity *formatdef.Struct, morpheEntity yaml.Entity, c
 
 	// We always need these for entities
 	imports.AddTyping("Optional", "List", "TYPE_CHECKING")
+	if primary, hasPrimary := morpheEntity.Identifiers["primary"]; hasPrimary && len(primary.Fields) > 1 {
+		imports.AddTyping("Tuple")
+	}
+
+	// Models are imported inside the generated methods, since they may import entities themselves
+	if mapping != nil {
+		imports.AddTypeCheckingImport("from .. import models")
+		imports.AddTypeCheckingImport("from sqlalchemy.sql import Select")
+		imports.AddSQLAlchemy("select")
+		if len(mapping.Joins) > 0 {
+			imports.AddFrom("sqlalchemy.orm", "aliased")
+		}
+	}
+
+	// Related entities and models are only imported for type checking, and referenced as forward references
+	var relatedNames []string
+	for name := range morpheEntity.Related {
+		relatedNames = append(relatedNames, name)
+	}
+	sort.Strings(relatedNames)
+	for _, relatedName := range relatedNames {
+		relation := morpheEntity.Related[relatedName]
+		targetNames := relation.For
+		if !yamlops.IsRelationPoly(string(relation.Type)) {
+			targetNames = []string{yamlops.GetRelationTargetName(relatedName, relation.Aliased)}
+		}
+		for _, targetName := range targetNames {
+			if _, isEntity := getEntityRelationTarget(targetName, r); isEntity {
+				if targetName == entity.Name {
+					continue
+				}
+				imports.AddTypeCheckingImport(fmt.Sprintf("from .%s import %s", toFileName(targetName), targetName))
+			} else {
+				imports.AddTypeCheckingImport("from .. import models")
+			}
+		}
+	}
+
+	// Loaders query through the models and map rows into the target entities
+	lazyLoadingStyle := getLazyLoadingStyle(entityConfig)
+	loadedAttributes := make(map[string]bool)
+	for _, loader := range loaders {
+		if lazyLoadingStyle == "property" {
+			loadedAttributes[loader.Attribute] = true
+		}
+		imports.TrackFieldType(loader.TargetType)
+		if loader.Unsupported != "" {
+			continue
+		}
+		imports.AddSQLAlchemy("select")
+		imports.AddFrom("sqlalchemy.orm", "aliased")
+		if len(loader.TargetKeys) > 1 {
+			imports.AddSQLAlchemy("tuple_")
+		}
+	}
+	if len(loaders) > 0 {
+		switch lazyLoadingStyle {
+		case "async":
+			imports.AddTypeCheckingImport("from sqlalchemy.ext.asyncio import AsyncSession")
+		case "sync":
+			imports.AddTypeCheckingImport("from sqlalchemy.orm import Session")
+		case "property":
+			imports.AddTypeCheckingImport("from sqlalchemy.orm import Session")
+			imports.AddFrom("functools", "cached_property")
+			imports.AddTyping("Callable", "ClassVar")
+		}
+	}
+
+	if entityConfig.IncludeValidation {
+		imports.AddFrom(".errors", "EntityValidationError")
+	}
 
 	// Add Literal if we have polymorphic type fields
 	if hasPolymorphicTypeField {
@@ -288,12 +832,12 @@ func generateEntityContent(entity *formatdef.Struct, morpheEntity yaml.Entity, c
 	cb.Line("")
 
 	// Generate class - entities are DTOs, not ORM models
-	if config.UseDataclass {
+	if immutability.Frozen {
+		cb.Line("@dataclass(frozen=True)")
+	} else if config.UseDataclass {
 		cb.Line("@dataclass")
-		cb.Line("class %s:", entity.Name)
-	} else {
-		cb.Line("class %s:", entity.Name)
 	}
+	cb.Line("class %s:", entity.Name)
 	cb.Indent()
 
 	// Add docstring
@@ -312,17 +856,55 @@ func generateEntityContent(entity *formatdef.Struct, morpheEntity yaml.Entity, c
 		}
 	}
 
-	// Add fields
+	// Immutable fields are read-only properties over private slots, next to a __dict__ for the rest
+	if len(immutability.ReadOnly) > 0 {
+		var slots []string
+		for _, field := range entity.Fields {
+			if immutability.ReadOnly[field.Name] {
+				slots = append(slots, fmt.Sprintf("'_%s'", SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name))))
+			}
+		}
+		cb.Line("__slots__ = (%s, '__dict__')", strings.Join(slots, ", "))
+	}
+
+	// Loaded properties need a session, so the entity class is bound to a session provider
+	if lazyLoadingStyle == "property" && len(loaders) > 0 {
+		cb.Line("session_provider: ClassVar[Optional[Callable[[], 'Session']]] = None")
+	}
+
+	// Add fields, with the defaulted ones last so dataclass arguments stay valid
+	var requiredLines, defaultedLines [][]string
+	var initParams, initAssignments []string
+	readOnlyComments := make(map[string][]string)
 	for _, field := range entity.Fields {
 		fieldName := SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name))
 		fieldType := field.Type.GetName()
+		if loadedAttributes[field.Name] {
+			continue
+		}
 
+		var lines []string
 		// Add identifier comment
 		if idType, isIdentifier := identifierFields[field.Name]; isIdentifier {
-			cb.Line("# %s identifier", idType)
+			lines = append(lines, fmt.Sprintf("# %s identifier", idType))
 		}
 
-		if config.AddTypeHints {
+		hasDefault := true
+		if morpheField, isEntityField := morpheEntity.Fields[field.Name]; isEntityField {
+			// Mandatory fields are required, anything else may be missing
+			mandatory := containsString(morpheField.Attributes, "mandatory")
+			if !mandatory {
+				fieldType = fmt.Sprintf("Optional[%s]", fieldType)
+			}
+			if !config.AddTypeHints {
+				lines = append(lines, fmt.Sprintf("%s = None", fieldName))
+			} else if mandatory || immutability.ReadOnly[field.Name] {
+				lines = append(lines, fmt.Sprintf("%s: %s", fieldName, fieldType))
+				hasDefault = false
+			} else {
+				lines = append(lines, fmt.Sprintf("%s: %s = None", fieldName, fieldType))
+			}
+		} else if config.AddTypeHints {
 			// Check if this is a polymorphic type field
 			if strings.HasSuffix(field.Name, "_type") && fieldType == "str" {
 				// Look for the corresponding relationship to get allowed types
@@ -335,57 +917,377 @@ func generateEntityContent(entity *formatdef.Struct, morpheEntity yaml.Entity, c
 					for _, forModel := range relation.For {
 						allowedTypes = append(allowedTypes, fmt.Sprintf("\"%s\"", forModel))
 					}
-					cb.Line("%s: Literal[%s]", fieldName, strings.Join(allowedTypes, ", "))
+					lines = append(lines, fmt.Sprintf("%s: Literal[%s]", fieldName, strings.Join(allowedTypes, ", ")))
 				} else {
-					cb.Line("%s: str", fieldName)
+					lines = append(lines, fmt.Sprintf("%s: str", fieldName))
 				}
+				hasDefault = false
 			} else if strings.HasPrefix(fieldType, "Optional[") || strings.HasPrefix(fieldType, "List[") || strings.Contains(fieldType, "Union[") {
 				// Relationship fields or Union types
-				cb.Line("%s: %s = None", fieldName, fieldType)
+				lines = append(lines, fmt.Sprintf("%s: %s = None", fieldName, fieldType))
 			} else if strings.HasSuffix(fieldName, "_id") || strings.HasSuffix(fieldName, "_type") {
 				// Foreign keys and type fields are optional
-				cb.Line("%s: Optional[%s] = None", fieldName, fieldType)
+				lines = append(lines, fmt.Sprintf("%s: Optional[%s] = None", fieldName, fieldType))
 			} else {
-				cb.Line("%s: %s", fieldName, fieldType)
+				lines = append(lines, fmt.Sprintf("%s: %s", fieldName, fieldType))
+				hasDefault = false
+			}
+		} else {
+			lines = append(lines, fmt.Sprintf("%s = None", fieldName))
+		}
+
+		// Read-only fields are declared by their property and set by __init__
+		declaration := lines[len(lines)-1]
+		if !strings.Contains(declaration, ":") {
+			declaration = strings.Replace(declaration, " = ", "=", 1)
+		}
+		initParams = append(initParams, declaration)
+		if immutability.ReadOnly[field.Name] {
+			initAssignments = append(initAssignments, fmt.Sprintf("self._%s = %s", fieldName, fieldName))
+			readOnlyComments[field.Name] = lines[:len(lines)-1]
+			continue
+		}
+		initAssignments = append(initAssignments, fmt.Sprintf("self.%s = %s", fieldName, fieldName))
+
+		if hasDefault && (config.UseDataclass || immutability.Frozen) {
+			defaultedLines = append(defaultedLines, lines)
+		} else {
+			requiredLines = append(requiredLines, lines)
+		}
+	}
+	for _, lines := range append(requiredLines, defaultedLines...) {
+		for _, line := range lines {
+			cb.Line("%s", line)
+		}
+	}
+
+	// Plain classes with read-only fields need an __init__ to set their private slots
+	if len(immutability.ReadOnly) > 0 {
+		cb.Line("")
+		cb.Line("def __init__(")
+		cb.Indent()
+		cb.Line("self,")
+		cb.Line("*,")
+		for _, param := range initParams {
+			cb.Line("%s,", param)
+		}
+		cb.Dedent()
+		cb.Line(") -> None:")
+		cb.Indent()
+		for _, assignment := range initAssignments {
+			cb.Line("%s", assignment)
+		}
+		if entityConfig.IncludeValidation {
+			cb.Line("self.validate()")
+		}
+		cb.Dedent()
+	}
+
+	// Add read-only accessors for the immutable fields
+	for _, field := range entity.Fields {
+		if !immutability.ReadOnly[field.Name] {
+			continue
+		}
+		fieldName := SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name))
+		cb.Line("")
+		for _, comment := range readOnlyComments[field.Name] {
+			cb.Line("%s", comment)
+		}
+		cb.Line("@property")
+		if config.AddTypeHints {
+			fieldType := field.Type.GetName()
+			if !containsString(morpheEntity.Fields[field.Name].Attributes, "mandatory") {
+				fieldType = fmt.Sprintf("Optional[%s]", fieldType)
 			}
+			cb.Line("def %s(self) -> %s:", fieldName, fieldType)
 		} else {
-			cb.Line("%s = None", fieldName)
+			cb.Line("def %s(self):", fieldName)
+		}
+		cb.Indent()
+		cb.Line(`"""Immutable %s."""`, fieldName)
+		cb.Line("return self._%s", fieldName)
+		cb.Dedent()
+	}
+
+	// Dataclasses with some immutable fields allow them to be set once
+	if len(immutability.WriteOnce) > 0 {
+		var names []string
+		for _, field := range entity.Fields {
+			if immutability.WriteOnce[field.Name] {
+				names = append(names, fmt.Sprintf("'%s'", SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name))))
+			}
+		}
+		if len(names) == 1 {
+			names[0] += ","
 		}
+		cb.Line("")
+		cb.Line("def __setattr__(self, name, value):")
+		cb.Indent()
+		cb.Line(`"""Reject changes to immutable fields once they are set."""`)
+		cb.Line("if name in (%s) and name in self.__dict__:", strings.Join(names, ", "))
+		cb.Indent()
+		cb.Line(`raise AttributeError(f"{name} is immutable")`)
+		cb.Dedent()
+		cb.Line("super().__setattr__(name, value)")
+		cb.Dedent()
+	}
+
+	// Add registry checks
+	if entityConfig.IncludeValidation {
+		cb.Line("")
+		renderEntityValidation(cb, entity, morpheEntity, config, r)
 	}
 
 	// Add identifier methods
 	if primary, hasPrimary := morpheEntity.Identifiers["primary"]; hasPrimary && len(primary.Fields) > 0 {
 		cb.Line("")
-		cb.Line("def get_id(self) -> str:")
+		var keyTypes, keyValues []string
+		for _, fieldName := range primary.Fields {
+			keyTypes = append(keyTypes, getEntityFieldAnnotation(entity, morpheEntity, fieldName))
+			keyValues = append(keyValues, "self."+SanitizePythonIdentifier(formatdef.ToSnakeCase(fieldName)))
+		}
+		// Composite identifiers are returned as a tuple in identifier order
+		keyType, keyValue := keyTypes[0], keyValues[0]
+		if len(primary.Fields) > 1 {
+			keyType = fmt.Sprintf("Tuple[%s]", strings.Join(keyTypes, ", "))
+			keyValue = "(" + strings.Join(keyValues, ", ") + ")"
+		}
+		cb.Line("def get_id(self) -> %s:", keyType)
 		cb.Indent()
 		cb.Line(`"""Get the primary identifier."""`)
-		cb.Line("return self.%s", formatdef.ToSnakeCase(primary.Fields[0]))
+		cb.Line("return %s", keyValue)
 		cb.Dedent()
+
+		if entityConfig.IdentityEquality {
+			renderEntityIdentityEquality(cb, entity.Name, primary.Fields)
+		}
 	}
 
-	// Add relationship loader methods
-	if len(morpheEntity.Related) > 0 {
-		for relName, relation := range morpheEntity.Related {
-			cb.Line("")
-			switch relation.Type {
-			case "HasMany", "ForMany":
-				// Use plural form for method name
-				cb.Line("async def load_%ss(self) -> List['%s']:", SanitizePythonIdentifier(formatdef.ToSnakeCase(relName)), relName)
-				cb.Indent()
-				cb.Line(`"""Load related %s entities."""`, relName)
-				cb.Line("# TODO: Implement lazy loading")
-				cb.Line("return []")
-				cb.Dedent()
-			default:
-				cb.Line("async def load_%s(self) -> Optional['%s']:", SanitizePythonIdentifier(formatdef.ToSnakeCase(relName)), relName)
-				cb.Indent()
-				cb.Line(`"""Load related %s entity."""`, relName)
-				cb.Line("# TODO: Implement lazy loading")
-				cb.Line("return None")
-				cb.Dedent()
+	// Add model mapper
+	if mapping != nil {
+		cb.Line("")
+		cb.Line("@classmethod")
+		cb.Line("def from_model(cls, %s: 'models.%s') -> '%s':", mapping.Parameter, mapping.RootModel, entity.Name)
+		cb.Indent()
+		cb.Line(`"""Create a %s entity from a %s model."""`, entity.Name, mapping.RootModel)
+		cb.Line("entity = cls.__new__(cls)")
+		for _, assignment := range mapping.Assignments {
+			if loadedAttributes[assignment.Attribute] {
+				continue
+			}
+			cb.Line("%s", immutability.renderAssignment(assignment.Attribute, assignment.Expression))
+		}
+		if entityConfig.IncludeValidation {
+			// cls.__new__ skips __init__ and __post_init__
+			cb.Line("entity.validate()")
+		}
+		cb.Line("return entity")
+		cb.Dedent()
+
+		cb.Line("")
+		cb.Line("@classmethod")
+		cb.Line("def select(cls) -> 'Select':")
+		cb.Indent()
+		cb.Line(`"""Select the %s fields as columns labeled like the entity attributes."""`, entity.Name)
+		cb.Line("from .. import models")
+		cb.Line("")
+		for _, join := range mapping.Joins {
+			cb.Line("%s = aliased(models.%s)", join.Alias, join.Model)
+		}
+		cb.Line("return (")
+		cb.Indent()
+		cb.Line("select(")
+		cb.Indent()
+		for _, assignment := range mapping.Assignments {
+			if assignment.Column != "" {
+				cb.Line("%s.label('%s'),", assignment.Column, assignment.Attribute)
+			}
+		}
+		cb.Dedent()
+		cb.Line(")")
+		cb.Line(".select_from(models.%s)", mapping.RootModel)
+		for _, join := range mapping.Joins {
+			method := "join"
+			if join.Outer {
+				method = "outerjoin"
+			}
+			cb.Line(".%s(%s.of_type(%s))", method, join.Relationship, join.Alias)
+		}
+		cb.Dedent()
+		cb.Line(")")
+		cb.Dedent()
+
+		cb.Line("")
+		cb.Line("@classmethod")
+		cb.Line("def from_row(cls, row) -> '%s':", entity.Name)
+		cb.Indent()
+		cb.Line(`"""Create a %s entity from a row returned by select()."""`, entity.Name)
+		cb.Line("entity = cls.__new__(cls)")
+		for _, assignment := range mapping.Assignments {
+			if loadedAttributes[assignment.Attribute] {
+				continue
+			}
+			if assignment.Column != "" {
+				cb.Line("%s", immutability.renderAssignment(assignment.Attribute, "row."+assignment.Attribute))
+			} else {
+				cb.Line("%s", immutability.renderAssignment(assignment.Attribute, assignment.Expression))
 			}
 		}
+		if entityConfig.IncludeValidation {
+			cb.Line("entity.validate()")
+		}
+		cb.Line("return entity")
+		cb.Dedent()
+	}
+
+	// Add relationship loaders
+	for _, loader := range loaders {
+		cb.Line("")
+		renderEntityLoader(cb, entity.Name, loader, lazyLoadingStyle)
 	}
+	cb.CustomRegion()
 
 	return cb.Build()
 }
+
+// getEntityFieldAnnotation returns the Python type of an entity field, Optional unless it is mandatory
+func getEntityFieldAnnotation(entity *formatdef.Struct, morpheEntity yaml.Entity, fieldName string) string {
+	var fieldType string
+	for _, field := range entity.Fields {
+		if field.Name == fieldName {
+			fieldType = field.Type.GetName()
+			break
+		}
+	}
+	if containsString(morpheEntity.Fields[fieldName].Attributes, "mandatory") {
+		return fieldType
+	}
+	return fmt.Sprintf("Optional[%s]", fieldType)
+}
+
+// renderEntityIdentityEquality renders __eq__ and __hash__ comparing entities by their primary identifier.
+// Entities without identifier values yet fall back to object identity.
+func renderEntityIdentityEquality(cb *formatdef.ContentBuilder, entityName string, primaryFields []string) {
+	var selfValues, otherValues []string
+	for _, fieldName := range primaryFields {
+		attribute := SanitizePythonIdentifier(formatdef.ToSnakeCase(fieldName))
+		selfValues = append(selfValues, "self."+attribute)
+		otherValues = append(otherValues, "other."+attribute)
+	}
+	selfKey, otherKey, missing := selfValues[0], otherValues[0], "key is None"
+	if len(primaryFields) > 1 {
+		selfKey = "(" + strings.Join(selfValues, ", ") + ")"
+		otherKey = "(" + strings.Join(otherValues, ", ") + ")"
+		missing = "None in key"
+	}
+
+	cb.Line("")
+	cb.Line("def __eq__(self, other):")
+	cb.Indent()
+	cb.Line(`"""Entities are equal when their primary identifiers are."""`)
+	cb.Line("if not isinstance(other, %s):", entityName)
+	cb.Indent()
+	cb.Line("return NotImplemented")
+	cb.Dedent()
+	cb.Line("key = %s", selfKey)
+	cb.Line("if %s:", missing)
+	cb.Indent()
+	cb.Line("return self is other")
+	cb.Dedent()
+	cb.Line("return key == %s", otherKey)
+	cb.Dedent()
+
+	cb.Line("")
+	cb.Line("def __hash__(self):")
+	cb.Indent()
+	cb.Line(`"""Hash the primary identifier."""`)
+	cb.Line("key = %s", selfKey)
+	cb.Line("if %s:", missing)
+	cb.Indent()
+	cb.Line("return object.__hash__(self)")
+	cb.Dedent()
+	cb.Line("return hash(key)")
+	cb.Dedent()
+}
+
+// renderEntityLoader renders the method or cached property loading a relation in the lazy loading style
+func renderEntityLoader(cb *formatdef.ContentBuilder, entityName string, loader entityLoader, lazyLoadingStyle string) {
+	target := loader.TargetType
+	returnType := fmt.Sprintf("Optional[%s]", target)
+	docstring := fmt.Sprintf("Load the related %s entity.", loader.TargetEntity)
+	if loader.Many {
+		returnType = fmt.Sprintf("List[%s]", target)
+		docstring = fmt.Sprintf("Load the related %s entities.", loader.TargetEntity)
+	}
+	if loader.TargetEntity == "" {
+		docstring = "Load the related entity."
+		if loader.Many {
+			docstring = "Load the related entities."
+		}
+	}
+
+	execute := "session.execute(query)"
+	switch lazyLoadingStyle {
+	case "async":
+		execute = "await session.execute(query)"
+		cb.Line("async def load_%s(self, session: 'AsyncSession') -> %s:", SanitizePythonIdentifier(loader.Attribute), returnType)
+	case "sync":
+		cb.Line("def load_%s(self, session: 'Session') -> %s:", SanitizePythonIdentifier(loader.Attribute), returnType)
+	case "property":
+		cb.Line("@cached_property")
+		cb.Line("def %s(self) -> %s:", SanitizePythonIdentifier(loader.Attribute), returnType)
+	}
+	cb.Indent()
+	cb.Line(`"""%s"""`, docstring)
+	if loader.Unsupported != "" {
+		cb.Line("raise NotImplementedError(%q)", loader.Unsupported)
+		cb.Dedent()
+		return
+	}
+	cb.Line("from .. import models")
+	cb.Line("from .%s import %s", toFileName(loader.TargetEntity), loader.TargetEntity)
+	cb.Line("")
+	if lazyLoadingStyle == "property" {
+		cb.Line("if type(self).session_provider is None:")
+		cb.Indent()
+		cb.Line(`raise RuntimeError("%s.session_provider must be set to load %s")`, entityName, loader.Attribute)
+		cb.Dedent()
+		cb.Line("session = type(self).session_provider()")
+	}
+
+	// The related rows are found by joining the aliased relation target from this entity's root model row
+	var relatedKeys, targetKeys, conditions []string
+	for _, key := range loader.TargetKeys {
+		relatedKeys = append(relatedKeys, "related."+key)
+		targetKeys = append(targetKeys, fmt.Sprintf("models.%s.%s", loader.TargetModel, key))
+	}
+	for _, key := range loader.SourceKeys {
+		conditions = append(conditions, fmt.Sprintf("%s == self.%s", key.Column, key.Attribute))
+	}
+	targetKey := targetKeys[0]
+	if len(targetKeys) > 1 {
+		targetKey = fmt.Sprintf("tuple_(%s)", strings.Join(targetKeys, ", "))
+	}
+	cb.Line("related = aliased(models.%s)", loader.TargetModel)
+	cb.Line("keys = (")
+	cb.Indent()
+	cb.Line("select(%s)", strings.Join(relatedKeys, ", "))
+	cb.Line(".select_from(models.%s)", loader.SourceModel)
+	cb.Line(".join(%s.of_type(related))", loader.Relationship)
+	cb.Line(".where(%s)", strings.Join(conditions, ", "))
+	cb.Dedent()
+	cb.Line(")")
+	cb.Line("query = %s.select().where(%s.in_(keys))", loader.TargetEntity, targetKey)
+	if loader.Many {
+		cb.Line("result = %s", execute)
+		cb.Line("return [%s.from_row(row) for row in result]", loader.TargetEntity)
+	} else {
+		if lazyLoadingStyle == "async" {
+			cb.Line("row = (%s).first()", execute)
+		} else {
+			cb.Line("row = %s.first()", execute)
+		}
+		cb.Line("return %s.from_row(row) if row is not None else None", loader.TargetEntity)
+	}
+	cb.Dedent()
+}
@@ -0,0 +1,603 @@
+package compile_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/internal/testutils"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+)
+
+// pythonEntityPrelude stubs the SQLAlchemy imports of the entity modules, so their plain Python
+// behaviour can be checked without SQLAlchemy installed
+const pythonEntityPrelude = `
+import sys
+import types
+from collections import namedtuple
+
+sqlalchemy = types.ModuleType('sqlalchemy')
+sqlalchemy.select = lambda *args: None
+sqlalchemy_orm = types.ModuleType('sqlalchemy.orm')
+sqlalchemy_orm.aliased = lambda *args: None
+sqlalchemy.orm = sqlalchemy_orm
+sys.modules['sqlalchemy'] = sqlalchemy
+sys.modules['sqlalchemy.orm'] = sqlalchemy_orm
+
+from working.entities.comment import Comment
+from working.entities.company import Company
+from working.entities.person import Person
+from working.enums.status import Status
+
+CommentRow = namedtuple('CommentRow', 'body id_ slug status commentable_type commentable_id')
+
+
+def expect_violations(create, violations):
+    # errors.py is only generated with validation
+    from working.entities.errors import EntityValidationError
+    try:
+        create()
+    except EntityValidationError as e:
+        assert e.violations == violations, e.violations
+        return
+    raise AssertionError('expected EntityValidationError')
+`
+
+type CompileEntitiesTestSuite struct {
+	generatedCodeFixture
+}
+
+func TestCompileEntitiesTestSuite(t *testing.T) {
+	suite.Run(t, new(CompileEntitiesTestSuite))
+}
+
+func (suite *CompileEntitiesTestSuite) SetupTest() {
+	suite.setupFixture("entities", pythonEntityPrelude)
+}
+
+func (suite *CompileEntitiesTestSuite) TearDownTest() {
+	suite.tearDownFixture()
+}
+
+// generatedCodeFixture compiles a test registry into the working directory and runs Python against the output.
+// Suites embed it and set it up with their registry and the prelude stubbing the generated imports.
+type generatedCodeFixture struct {
+	suite.Suite
+
+	TestDirPath    string
+	WorkingDirPath string
+	RegistryConfig rcfg.MorpheLoadRegistryConfig
+	PythonPrelude  string
+}
+
+func (fixture *generatedCodeFixture) setupFixture(registryName string, pythonPrelude string) {
+	fixture.TestDirPath = testutils.GetTestDirPath()
+	fixture.WorkingDirPath = filepath.Join(fixture.TestDirPath, "working")
+	registryDirPath := filepath.Join(fixture.TestDirPath, "registry", registryName)
+	fixture.RegistryConfig = rcfg.MorpheLoadRegistryConfig{
+		RegistryEnumsDirPath:      filepath.Join(registryDirPath, "enums"),
+		RegistryStructuresDirPath: filepath.Join(registryDirPath, "structures"),
+		RegistryModelsDirPath:     filepath.Join(registryDirPath, "models"),
+		RegistryEntitiesDirPath:   filepath.Join(registryDirPath, "entities"),
+	}
+	fixture.PythonPrelude = pythonPrelude
+	fixture.Require().NoError(os.Mkdir(fixture.WorkingDirPath, 0755))
+}
+
+func (fixture *generatedCodeFixture) tearDownFixture() {
+	os.RemoveAll(fixture.WorkingDirPath)
+}
+
+func (fixture *generatedCodeFixture) compile(formatConfig compile.SQLAlchemyConfig, morpheConfig cfg.MorpheConfig) {
+	config := compile.MorpheCompileConfig{FormatConfig: formatConfig}
+	config.MorpheConfig = morpheConfig
+	fixture.compileConfig(config)
+}
+
+// compileConfig compiles the registry to the working directory with the output options of the config
+func (fixture *generatedCodeFixture) compileConfig(config compile.MorpheCompileConfig) {
+	config.MorpheLoadRegistryConfig = fixture.RegistryConfig
+	config.OutputPath = fixture.WorkingDirPath
+	config.FormatConfig.UseDeclarative = true
+	config.FormatConfig.AddTypeHints = true
+	config.FormatConfig.IndentSize = 4
+	config.FormatConfig.PythonVersion = "3.8"
+	fixture.Require().NoError(compile.MorpheToSQLAlchemy(config))
+}
+
+func (fixture *generatedCodeFixture) readOutput(relativePath string) string {
+	content, err := os.ReadFile(filepath.Join(fixture.WorkingDirPath, relativePath))
+	fixture.Require().NoError(err)
+	return string(content)
+}
+
+// runPython runs a script after the prelude against the generated output, skipping the test without Python
+func (fixture *generatedCodeFixture) runPython(script string) {
+	runPythonScript(&fixture.Suite, fixture.TestDirPath, fixture.PythonPrelude+script)
+}
+
+// runPythonScript runs a script from the test directory, so the generated output imports as the working package
+func runPythonScript(s *suite.Suite, testDirPath string, script string) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		s.T().Skip("Python not available to run the generated code")
+	}
+
+	scriptPath := filepath.Join(testDirPath, "check_generated.py")
+	s.Require().NoError(os.WriteFile(scriptPath, []byte(script), 0644))
+	defer os.Remove(scriptPath)
+
+	cmd := exec.Command(python, "-B", scriptPath)
+	cmd.Dir = testDirPath
+	output, err := cmd.CombinedOutput()
+	s.NoError(err, string(output))
+}
+
+func (suite *CompileEntitiesTestSuite) TestValidation_Checks() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{Entities: cfg.EntityConfig{IncludeValidation: true}})
+
+	comment := suite.readOutput("entities/comment.py")
+	suite.Contains(comment, "def validate(self) -> None:")
+	suite.Contains(comment, `violations.append("body is mandatory")`)
+	suite.Contains(comment, `violations.append("slug is required by the slug identifier")`)
+	suite.Contains(comment, "not isinstance(self.status, Status)")
+	suite.Contains(comment, "self.commentable_type not in ('Person', 'Company')")
+	suite.NotContains(comment, "__post_init__")
+
+	suite.runPython(`
+valid = CommentRow('Hello', 1, 'hello', Status.DRAFT, 'Person', '1')
+comment = Comment.from_row(valid)
+assert comment.id_ == 1
+
+# Every violation is reported at once
+expect_violations(
+    lambda: Comment.from_row(CommentRow(None, None, None, 'draft', 'Invoice', '1')),
+    [
+        'body is mandatory',
+        'id_ is mandatory',
+        'slug is required by the slug identifier',
+        "status must be a Status member, got 'draft'",
+        "commentable_type must be one of Person, Company, got 'Invoice'",
+    ],
+)
+try:
+    Comment.from_row(CommentRow('Hello', 1, None, None, None, None))
+except ValueError as e:
+    assert str(e) == 'invalid Comment: slug is required by the slug identifier', str(e)
+    assert e.entity == 'Comment'
+else:
+    raise AssertionError('expected EntityValidationError')
+`)
+}
+
+func (suite *CompileEntitiesTestSuite) TestValidation_Factories() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{Entities: cfg.EntityConfig{IncludeValidation: true}})
+
+	comment := suite.readOutput("entities/comment.py")
+	// from_model() and from_row() bypass __init__, so they validate explicitly
+	suite.Equal(2, strings.Count(comment, "        entity.validate()\n        return entity"))
+
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+	comment = suite.readOutput("entities/comment.py")
+	suite.NotContains(comment, "validate")
+}
+
+func (suite *CompileEntitiesTestSuite) TestValidation_Dataclass() {
+	suite.compile(compile.SQLAlchemyConfig{UseDataclass: true}, cfg.MorpheConfig{Entities: cfg.EntityConfig{IncludeValidation: true}})
+
+	comment := suite.readOutput("entities/comment.py")
+	suite.Contains(comment, "def __post_init__(self) -> None:\n        self.validate()")
+	suite.Contains(comment, "def validate(self) -> None:")
+
+	suite.runPython(`
+expect_violations(
+    lambda: Comment(body=None, id_=1, slug='hello', commentable_type='Person'),
+    ['body is mandatory'],
+)
+expect_violations(
+    lambda: Comment.from_row(CommentRow('Hello', 1, 'hello', None, 'Invoice', None)),
+    ["commentable_type must be one of Person, Company, got 'Invoice'"],
+)
+Comment(body='Hello', id_=1, slug='hello', commentable_type='Company', status=Status.PUBLISHED)
+`)
+}
+
+func (suite *CompileEntitiesTestSuite) TestImmutability_PlainInit() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{Entities: cfg.EntityConfig{IncludeValidation: true}})
+
+	comment := suite.readOutput("entities/comment.py")
+	suite.Contains(comment, "__slots__ = ('_id_', '__dict__')")
+	suite.Contains(comment, "        self._id_ = id_\n")
+	// The property declares id_, so no class annotation shadows it
+	suite.NotContains(comment, "    id_: int\n")
+
+	suite.runPython(`
+comment = Comment(body='Hello', id_=1, slug='hello', commentable_type='Person')
+assert comment.id_ == 1
+assert comment.status is None
+assert 'id_' not in Comment.__annotations__
+try:
+    comment.id_ = 2
+except AttributeError:
+    pass
+else:
+    raise AssertionError('expected id_ to be read-only')
+comment.body = 'Edited'
+assert comment.body == 'Edited'
+
+# The constructor validates like the factories do
+expect_violations(
+    lambda: Comment(body=None, id_=1, slug='hello', commentable_type='Person'),
+    ['body is mandatory'],
+)
+`)
+}
+
+func (suite *CompileEntitiesTestSuite) TestIdentity_GetID() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+
+	comment := suite.readOutput("entities/comment.py")
+	suite.Contains(comment, "def get_id(self) -> int:\n        \"\"\"Get the primary identifier.\"\"\"\n        return self.id_\n")
+	person := suite.readOutput("entities/person.py")
+	suite.Contains(person, "def get_id(self) -> Tuple[Optional[str], Optional[str]]:")
+	suite.Contains(person, "return (self.first_name, self.last_name)")
+	suite.Contains(person, "from typing import List, Optional, TYPE_CHECKING, Tuple")
+
+	suite.runPython(`
+comment = Comment(body='Hello', id_=7, slug='hello', commentable_type='Person')
+assert comment.get_id() == 7
+
+person = Person()
+person.first_name = 'Ada'
+person.last_name = 'Lovelace'
+assert person.get_id() == ('Ada', 'Lovelace')
+`)
+}
+
+func (suite *CompileEntitiesTestSuite) TestIdentity_Equality() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{Entities: cfg.EntityConfig{IdentityEquality: true}})
+
+	suite.Contains(suite.readOutput("entities/comment.py"), "def __hash__(self):")
+
+	suite.runPython(`
+def new_person(first_name, last_name, id_=None):
+    person = Person()
+    person.first_name = first_name
+    person.last_name = last_name
+    person.id_ = id_
+    return person
+
+# Single identifiers compare by value
+first = Comment(body='Hello', id_=1, slug='hello', commentable_type='Person')
+same = Comment(body='Edited', id_=1, slug='edited', commentable_type='Company')
+other = Comment(body='Hello', id_=2, slug='hello', commentable_type='Person')
+assert first == same and hash(first) == hash(same)
+assert first != other
+assert len({first, same, other}) == 2
+
+# Composite identifiers compare by every field
+ada = new_person('Ada', 'Lovelace', 1)
+assert ada == new_person('Ada', 'Lovelace', 2)
+assert hash(ada) == hash(('Ada', 'Lovelace'))
+assert ada != new_person('Ada', 'Byron', 1)
+
+# Entities without an identifier fall back to object identity
+unsaved = new_person('Ada', None)
+assert unsaved == unsaved
+assert unsaved != new_person('Ada', None)
+assert hash(unsaved) == object.__hash__(unsaved)
+
+# Other types are never equal
+assert ada != ('Ada', 'Lovelace')
+`)
+}
+
+func (suite *CompileEntitiesTestSuite) TestIdentity_ModelHelpers() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{Models: cfg.ModelConfig{IdentityHelpers: true}})
+
+	person := suite.readOutput("models/person.py")
+	suite.Contains(person, "def identity_key(self) -> Tuple:\n        \"\"\"Return the primary identifier values of the row.\"\"\"\n        return (self.id_,)\n")
+	suite.Contains(person, "async def get_by_id(cls, session: 'AsyncSession', id_: int) -> Optional['Person']:")
+	suite.Contains(person, "async def get_by_name(cls, session: 'AsyncSession', first_name: str, last_name: str) -> Optional['Person']:")
+	suite.Contains(person, "query = select(cls).where(cls.first_name == first_name, cls.last_name == last_name)")
+	comment := suite.readOutput("models/comment.py")
+	suite.Contains(comment, "async def get_by_slug(cls, session: 'AsyncSession', slug: str) -> Optional['Comment']:")
+	suite.Contains(comment, "result = await session.execute(query)")
+
+	// Sync lazy loading uses a Session
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{
+		Models:   cfg.ModelConfig{IdentityHelpers: true},
+		Entities: cfg.EntityConfig{LazyLoadingStyle: "sync"},
+	})
+	comment = suite.readOutput("models/comment.py")
+	suite.Contains(comment, "    def get_by_slug(cls, session: 'Session', slug: str) -> Optional['Comment']:")
+	suite.Contains(comment, "result = session.execute(query)")
+	suite.NotContains(comment, "async def")
+
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+	person = suite.readOutput("models/person.py")
+	suite.NotContains(person, "identity_key")
+	suite.NotContains(person, "get_by_")
+}
+
+func (suite *CompileEntitiesTestSuite) TestNavigation_PolymorphicUnion() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+
+	comment := suite.readOutput("entities/comment.py")
+	suite.Contains(comment, "    commentable: Optional[Union['Person', 'Company']] = None\n")
+
+	suite.runPython(`
+import typing
+from typing import Optional, Union
+
+import working.entities.comment as comment_module
+
+# ForOnePoly relations resolve to a Union of the entities they are for
+names = {**vars(comment_module), 'Company': Company, 'Person': Person, 'AsyncSession': object, 'Select': object}
+assert typing.get_type_hints(Comment, globalns=names)['commentable'] == Optional[Union[Person, Company]]
+assert typing.get_type_hints(Comment.load_commentable, globalns=names)['return'] == Optional[Union[Person, Company]]
+`)
+}
+
+// pythonEntityQueryPrelude replaces select(), aliased() and the models with fakes rendering the queries
+// the entities build as text, and records them in fake sessions, so the queries of the minimal registry's
+// entities can be checked without a database
+const pythonEntityQueryPrelude = `
+import sys
+import types
+from collections import namedtuple
+
+
+class Expression:
+    def __init__(self, sql):
+        self.sql = sql
+
+    def label(self, name):
+        return Expression(f'{self.sql} AS {name}')
+
+    def of_type(self, alias):
+        return Expression(f'{self.sql} TO {alias.sql}')
+
+    def in_(self, query):
+        return Expression(f'{self.sql} IN ({query.sql})')
+
+    def __eq__(self, value):
+        return Expression(f'{self.sql} = {value!r}')
+
+    __hash__ = object.__hash__
+
+
+class FakeModel(Expression):
+    def __getattr__(self, name):
+        if name.startswith('__'):
+            raise AttributeError(name)
+        return Expression(f'{self.sql}.{name}')
+
+
+class FakeSelect:
+    def __init__(self, sql):
+        self.sql = sql
+
+    def select_from(self, model):
+        return FakeSelect(f'{self.sql} FROM {model.sql}')
+
+    def join(self, target):
+        return FakeSelect(f'{self.sql} JOIN {target.sql}')
+
+    def outerjoin(self, target):
+        return FakeSelect(f'{self.sql} LEFT OUTER JOIN {target.sql}')
+
+    def where(self, *conditions):
+        return FakeSelect(f'{self.sql} WHERE ' + ' AND '.join(condition.sql for condition in conditions))
+
+
+sqlalchemy = types.ModuleType('sqlalchemy')
+sqlalchemy.select = lambda *columns: FakeSelect('SELECT ' + ', '.join(column.sql for column in columns))
+sqlalchemy_orm = types.ModuleType('sqlalchemy.orm')
+sqlalchemy_orm.aliased = lambda model: FakeModel(f'aliased({model.sql})')
+sys.modules['sqlalchemy'] = sqlalchemy
+sys.modules['sqlalchemy.orm'] = sqlalchemy_orm
+
+models = types.ModuleType('working.models')
+models.__getattr__ = lambda name: FakeModel(name)
+sys.modules['working.models'] = models
+
+
+
+class FakeResult(list):
+    def first(self):
+        return self[0] if self else None
+
+
+class FakeSession:
+    def __init__(self, rows):
+        self.rows = rows
+        self.queries = []
+
+    def execute(self, query):
+        self.queries.append(query.sql)
+        return FakeResult(self.rows)
+
+
+class FakeAsyncSession(FakeSession):
+    async def execute(self, query):
+        return FakeSession.execute(self, query)
+
+
+from working.entities.company import Company
+from working.entities.person import Person
+
+CompanyRow = namedtuple('CompanyRow', 'id_ name tax_id')
+PersonRow = namedtuple('PersonRow', 'email id_ last_name nationality company_id')
+`
+
+type CompileEntityQueriesTestSuite struct {
+	generatedCodeFixture
+}
+
+func TestCompileEntityQueriesTestSuite(t *testing.T) {
+	suite.Run(t, new(CompileEntityQueriesTestSuite))
+}
+
+func (suite *CompileEntityQueriesTestSuite) SetupTest() {
+	suite.setupFixture("minimal", pythonEntityQueryPrelude)
+}
+
+func (suite *CompileEntityQueriesTestSuite) TearDownTest() {
+	suite.tearDownFixture()
+}
+
+func (suite *CompileEntityQueriesTestSuite) TestSelect_Joins() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+
+	suite.runPython(`
+# Fields of the root model are selected directly
+assert Company.select().sql == (
+    'SELECT Company.id_ AS id_, Company.name AS name, Company.tax_id AS tax_id FROM Company'
+), Company.select().sql
+
+# Field paths through an optional relation are outer joined, labeled like the entity attributes
+assert Person.select().sql == (
+    'SELECT aliased(ContactInfo).email AS email, Person.id_ AS id_, Person.last_name AS last_name, '
+    'Person.nationality AS nationality, Person.company_id AS company_id '
+    'FROM Person LEFT OUTER JOIN Person.contact_info TO aliased(ContactInfo)'
+), Person.select().sql
+
+# The labeled rows build the entities
+person = Person.from_row(PersonRow('ada@example.com', 1, 'Lovelace', None, '2'))
+assert person.email == 'ada@example.com' and person.id_ == 1 and person.company_id == '2'
+assert person.company is None
+person = Person.from_row(PersonRow(None, 2, 'Hopper', None, '2'))
+assert person.email is None
+company = Company.from_row(CompanyRow(2, 'Acme', 'DE123'))
+assert company.id_ == 2 and company.name == 'Acme' and company.people == []
+`)
+}
+
+// loaderQueries are the queries loading a company's people and a person's company
+const loaderQueries = `
+company_query = (
+    'SELECT aliased(ContactInfo).email AS email, Person.id_ AS id_, Person.last_name AS last_name, '
+    'Person.nationality AS nationality, Person.company_id AS company_id '
+    'FROM Person LEFT OUTER JOIN Person.contact_info TO aliased(ContactInfo) '
+    'WHERE Person.id_ IN (SELECT aliased(Person).id_ FROM Company JOIN Company.person TO aliased(Person) WHERE Company.id_ = 2)'
+)
+person_query = (
+    'SELECT Company.id_ AS id_, Company.name AS name, Company.tax_id AS tax_id FROM Company '
+    'WHERE Company.id_ IN (SELECT aliased(Company).id_ FROM Person JOIN Person.company TO aliased(Company) WHERE Person.id_ = 1)'
+)
+company = Company.from_row(CompanyRow(2, 'Acme', 'DE123'))
+person = Person.from_row(PersonRow(None, 1, 'Lovelace', None, '2'))
+people_rows = [PersonRow('ada@example.com', 1, 'Lovelace', None, '2'), PersonRow(None, 3, 'Hopper', None, '2')]
+`
+
+func (suite *CompileEntityQueriesTestSuite) TestLoaders_Async() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+
+	company := suite.readOutput("entities/company.py")
+	suite.Contains(company, "async def load_people(self, session: 'AsyncSession') -> List['Person']:")
+
+	suite.runPython(loaderQueries + `
+import asyncio
+
+session = FakeAsyncSession(people_rows)
+people = asyncio.run(company.load_people(session))
+assert [p.id_ for p in people] == [1, 3] and isinstance(people[0], Person)
+assert people[0].email == 'ada@example.com'
+assert session.queries == [company_query], session.queries
+
+session = FakeAsyncSession([CompanyRow(2, 'Acme', 'DE123')])
+loaded = asyncio.run(person.load_company(session))
+assert isinstance(loaded, Company) and loaded.name == 'Acme'
+assert session.queries == [person_query], session.queries
+assert asyncio.run(person.load_company(FakeAsyncSession([]))) is None
+`)
+}
+
+func (suite *CompileEntityQueriesTestSuite) TestLoaders_Sync() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{Entities: cfg.EntityConfig{LazyLoadingStyle: "sync"}})
+
+	company := suite.readOutput("entities/company.py")
+	suite.Contains(company, "    def load_people(self, session: 'Session') -> List['Person']:")
+	suite.NotContains(company, "async def")
+
+	suite.runPython(loaderQueries + `
+session = FakeSession(people_rows)
+people = company.load_people(session)
+assert [p.id_ for p in people] == [1, 3]
+assert session.queries == [company_query], session.queries
+
+session = FakeSession([CompanyRow(2, 'Acme', 'DE123')])
+assert person.load_company(session).id_ == 2
+assert session.queries == [person_query], session.queries
+assert person.load_company(FakeSession([])) is None
+`)
+}
+
+func (suite *CompileEntityQueriesTestSuite) TestLoaders_Property() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{Entities: cfg.EntityConfig{LazyLoadingStyle: "property"}})
+
+	company := suite.readOutput("entities/company.py")
+	suite.Contains(company, "@cached_property\n    def people(self) -> List['Person']:")
+	suite.NotContains(company, "load_people")
+
+	suite.runPython(loaderQueries + `
+# Without a session provider the relation can't be loaded
+try:
+    company.people
+except RuntimeError as e:
+    assert str(e) == 'Company.session_provider must be set to load people', str(e)
+else:
+    raise AssertionError('expected RuntimeError')
+
+session = FakeSession(people_rows)
+Company.session_provider = lambda: session
+people = company.people
+assert [p.id_ for p in people] == [1, 3]
+assert session.queries == [company_query], session.queries
+
+# The loaded relation is cached on the entity
+assert company.people is people
+assert len(session.queries) == 1
+
+session = FakeSession([CompanyRow(2, 'Acme', 'DE123')])
+Person.session_provider = lambda: session
+assert person.company.name == 'Acme'
+assert session.queries == [person_query], session.queries
+`)
+}
+
+func (suite *CompileEntityQueriesTestSuite) TestNavigation_Types() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+
+	company := suite.readOutput("entities/company.py")
+	suite.Contains(company, "    people: List['Person'] = None\n")
+
+	suite.runPython(`
+import typing
+from typing import List, Optional
+
+import working.entities.company as company_module
+import working.entities.person as person_module
+
+# The entities import, so navigation types only imported for type checking are forward references.
+# With those imports in scope, they resolve to the related entities.
+type_checking_names = {'Company': Company, 'Person': Person, 'models': models, 'AsyncSession': object, 'Select': object}
+
+
+def hints(value, module):
+    return typing.get_type_hints(value, globalns={**vars(module), **type_checking_names})
+
+
+assert hints(Company, company_module)['people'] == List[Person]
+assert hints(Person, person_module)['company'] == Optional[Company]
+assert hints(Company.load_people, company_module)['return'] == List[Person]
+assert hints(Person.load_company, person_module)['return'] == Optional[Company]
+`)
+}
@@ -92,8 +92,7 @@ func generateEnumContent(enum *formatdef.Enum, config SQLAlchemyConfig) []byte {
 	// Add enum entries
 	for _, entry := range enum.Entries {
 		// Python enum format: NAME = value
-		entryName := formatdef.ToSnakeCase(entry.Name)
-		entryName = strings.ToUpper(entryName)
+		entryName := getEnumMemberName(entry.Name)
 
 		switch enum.Type.GetName() {
 		case "str":
@@ -118,6 +117,12 @@ func generateEnumContent(enum *formatdef.Enum, config SQLAlchemyConfig) []byte {
 	cb.Dedent()
 	cb.Line("raise ValueError(f\"No %s member with value {value}\")", enum.Name)
 	cb.Dedent()
+	cb.CustomRegion()
 
 	return cb.Build()
 }
+
+// getEnumMemberName returns the Python member name for an enum entry
+func getEnumMemberName(entryName string) string {
+	return strings.ToUpper(formatdef.ToSnakeCase(entryName))
+}
@@ -23,6 +23,55 @@ func ErrEnumNotFound(enumName string) error {
 	return fmt.Errorf("enum not found: %s", enumName)
 }
 
+// ErrInvalidTableConfig is wrapped by errors in per-model table configuration
+var ErrInvalidTableConfig = fmt.Errorf("invalid table configuration")
+
+// ErrTableConfigModelNotFound is returned when a table override names a model missing from the registry
+func ErrTableConfigModelNotFound(modelName string) error {
+	return fmt.Errorf("%w: unknown model %s", ErrInvalidTableConfig, modelName)
+}
+
+// ErrColumnConfigFieldNotFound is returned when a column override names a field missing from its model
+func ErrColumnConfigFieldNotFound(modelName string, fieldName string) error {
+	return fmt.Errorf("%w: unknown field %s.%s", ErrInvalidTableConfig, modelName, fieldName)
+}
+
+// ErrStorageConfigNotStructure is returned when a structure storage override names a field that isn't a structure
+func ErrStorageConfigNotStructure(modelName string, fieldName string) error {
+	return fmt.Errorf("%w: field %s.%s is not a structure", ErrInvalidTableConfig, modelName, fieldName)
+}
+
+// ErrForeignKeyToSkippedModel is returned when a generated model's foreign key references a skipped model
+func ErrForeignKeyToSkippedModel(modelName string, relatedName string, targetName string) error {
+	return fmt.Errorf("%w: relation %s.%s references skipped model %s, whose table is not generated", ErrInvalidTableConfig, modelName, relatedName, targetName)
+}
+
+// ErrTableNameCollision is returned when two models map to the same table
+func ErrTableNameCollision(tableName string, firstModel string, secondModel string) error {
+	return fmt.Errorf("%w: models %s and %s both map to table %s", ErrInvalidTableConfig, firstModel, secondModel, tableName)
+}
+
+// ErrStaleOutput is wrapped by the error check mode returns when the generated output differs from the files on disk
+var ErrStaleOutput = fmt.Errorf("generated output is stale")
+
+// ErrStaleOutputFiles is returned in check mode when generated files are missing or differ
+func ErrStaleOutputFiles(count int) error {
+	return fmt.Errorf("%w: %d files differ from the registry", ErrStaleOutput, count)
+}
+
+// ErrModifiedOutput is wrapped by the errors returned when regenerating would lose changes made to a generated file
+var ErrModifiedOutput = fmt.Errorf("generated file was modified")
+
+// ErrModifiedOutputFile is returned when a generated file was edited outside its custom regions
+func ErrModifiedOutputFile(path string) error {
+	return fmt.Errorf("%w: %s no longer matches its hash, move the changes into a custom region or force the compile", ErrModifiedOutput, path)
+}
+
+// ErrCustomRegionDropped is returned when the regenerated file has no region for the custom code of an existing one
+func ErrCustomRegionDropped(path string, region string) error {
+	return fmt.Errorf("%w: %s has custom code in region %s that the regenerated file has no region for", ErrModifiedOutput, path, region)
+}
+
 // Python-specific errors
 func ErrReservedKeyword(word string) error {
 	return fmt.Errorf("'%s' is a reserved Python keyword", word)
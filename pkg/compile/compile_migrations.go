@@ -0,0 +1,451 @@
+package compile
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/typemap"
+)
+
+// defaultMigrationMessage is used when no revision message is configured
+const defaultMigrationMessage = "morphe schema update"
+
+// CompileMigration diffs the configured previous schema against the registry and
+// writes the resulting Alembic revision
+func CompileMigration(config MorpheCompileConfig, r *registry.Registry, writer *MorpheWriter) error {
+	current, err := CompileSchema(config, r)
+	if err != nil {
+		return err
+	}
+
+	if config.Migrations.ExportSchema {
+		snapshot, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to export schema: %w", err)
+		}
+		if err := writer.WriteSchemaSnapshot(append(snapshot, '\n')); err != nil {
+			return err
+		}
+	}
+
+	if !config.Migrations.Enabled {
+		return nil
+	}
+
+	previous, err := loadPreviousSchema(config)
+	if err != nil {
+		return fmt.Errorf("failed to load previous schema: %w", err)
+	}
+
+	changes := DiffSchemas(previous, current)
+	if len(changes) == 0 {
+		fmt.Println("No schema changes detected, skipping migration")
+		return nil
+	}
+
+	revision, content := generateMigrationContent(changes, previous, current, config.Migrations, config.DDL.GetDialect())
+	return writer.WriteMigration(revision+"_"+toMigrationSlug(getMigrationMessage(config.Migrations)), content)
+}
+
+// loadPreviousSchema loads the schema snapshot the migration is diffed against
+func loadPreviousSchema(config MorpheCompileConfig) (*formatdef.Schema, error) {
+	migrations := config.Migrations
+
+	if migrations.PreviousSchemaPath != "" {
+		data, err := os.ReadFile(migrations.PreviousSchemaPath)
+		if err != nil {
+			return nil, err
+		}
+		var schema formatdef.Schema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("invalid schema snapshot %s: %w", migrations.PreviousSchemaPath, err)
+		}
+		return &schema, nil
+	}
+
+	if migrations.PreviousRegistryPath != "" {
+		previousConfig := config
+		previousConfig.MorpheLoadRegistryConfig = rcfg.MorpheLoadRegistryConfig{
+			RegistryEnumsDirPath:      path.Join(migrations.PreviousRegistryPath, "enums"),
+			RegistryModelsDirPath:     path.Join(migrations.PreviousRegistryPath, "models"),
+			RegistryStructuresDirPath: path.Join(migrations.PreviousRegistryPath, "structures"),
+			RegistryEntitiesDirPath:   path.Join(migrations.PreviousRegistryPath, "entities"),
+		}
+		r, err := registry.LoadMorpheRegistry(registry.LoadMorpheRegistryHooks{}, previousConfig.MorpheLoadRegistryConfig)
+		if err != nil {
+			return nil, err
+		}
+		return CompileSchema(previousConfig, r)
+	}
+
+	// Without a snapshot the migration creates the whole schema
+	return &formatdef.Schema{}, nil
+}
+
+// generateMigrationContent renders an Alembic revision for the given changes
+func generateMigrationContent(changes []SchemaChange, previous *formatdef.Schema, current *formatdef.Schema, config cfg.MigrationConfig, dialect string) (string, []byte) {
+	upgrade := formatdef.NewContentBuilder("    ")
+	upgrade.Indent()
+	renderMigrationChanges(upgrade, changes, current, previous, dialect)
+
+	downgrade := formatdef.NewContentBuilder("    ")
+	downgrade.Indent()
+	renderMigrationChanges(downgrade, InvertChanges(changes), previous, current, dialect)
+
+	// The revision id is derived from the content so regeneration is stable
+	hash := sha1.Sum([]byte(config.DownRevision + upgrade.String() + downgrade.String()))
+	revision := hex.EncodeToString(hash[:])[:12]
+
+	cb := formatdef.NewContentBuilder("    ")
+	cb.Line(`"""%s`, getMigrationMessage(config))
+	cb.Line("")
+	cb.Line("Revision ID: %s", revision)
+	if config.DownRevision != "" {
+		cb.Line("Revises: %s", config.DownRevision)
+	} else {
+		cb.Line("Revises:")
+	}
+	cb.Line(`"""`)
+	cb.Line("from alembic import op")
+	cb.Line("import sqlalchemy as sa")
+	if strings.Contains(upgrade.String()+downgrade.String(), "postgresql.") {
+		cb.Line("from sqlalchemy.dialects import postgresql")
+	}
+	cb.Line("")
+	cb.Line("")
+	cb.Line("# revision identifiers, used by Alembic.")
+	cb.Line("revision = '%s'", revision)
+	if config.DownRevision != "" {
+		cb.Line("down_revision = '%s'", config.DownRevision)
+	} else {
+		cb.Line("down_revision = None")
+	}
+	cb.Line("branch_labels = None")
+	cb.Line("depends_on = None")
+	cb.Line("")
+	cb.Line("")
+	cb.Line("def upgrade():")
+	cb.Line("%s", upgrade.String())
+	cb.Line("")
+	cb.Line("")
+	cb.Line("def downgrade():")
+	cb.Line("%s", downgrade.String())
+	cb.Line("")
+
+	return revision, cb.Build()
+}
+
+// renderMigrationChanges renders Alembic operations for the changes.
+// target is the schema after the changes, source the schema before them.
+// Views are created with raw SQL in the given dialect.
+func renderMigrationChanges(cb *formatdef.ContentBuilder, changes []SchemaChange, target *formatdef.Schema, source *formatdef.Schema, dialect string) {
+	rendered := 0
+	rewrittenEnums := make(map[string]bool)
+	for _, change := range changes {
+		if change.IsDestructive() {
+			cb.Line("# DESTRUCTIVE: %s", describeSchemaChange(change))
+		} else if change.NeedsReview() {
+			cb.Line("# REVIEW: %s", describeSchemaChange(change))
+		}
+
+		switch change.Kind {
+		case ChangeCreateTable:
+			cb.Line("op.create_table(")
+			cb.Indent()
+			cb.Line("'%s',", change.Table.Name)
+			for _, column := range change.Table.Columns {
+				cb.Line("%s,", renderMigrationColumn(column, target, dialect))
+			}
+			if change.Table.PrimaryKeyName != "" {
+				var primaryKeys []string
+				for _, column := range change.Table.Columns {
+					if column.PrimaryKey {
+						primaryKeys = append(primaryKeys, column.Name)
+					}
+				}
+				cb.Line("sa.PrimaryKeyConstraint(%s, name='%s'),", quoteColumnList(primaryKeys), change.Table.PrimaryKeyName)
+			}
+			for _, fk := range change.Table.ForeignKeys {
+				cb.Line("sa.ForeignKeyConstraint(['%s'], ['%s.%s'], name='%s'),", fk.Column, fk.QualifiedRefTable(), fk.RefColumn, fk.Name)
+			}
+			for _, unique := range change.Table.Uniques {
+				cb.Line("sa.UniqueConstraint(%s, name='%s'),", quoteColumnList(unique.Columns), unique.Name)
+			}
+			if change.Table.Schema != "" {
+				cb.Line("schema=%s,", quotePythonString(change.Table.Schema))
+			}
+			if change.Table.Comment != "" {
+				cb.Line("comment=%s,", quotePythonString(change.Table.Comment))
+			}
+			cb.Dedent()
+			cb.Line(")")
+		case ChangeDropTable:
+			cb.Line("op.drop_table('%s'%s)", change.Table.Name, renderSchemaArg("schema", change.Table.Schema))
+		case ChangeAddColumn:
+			cb.Line("op.add_column('%s', %s%s)", change.Table.Name, renderMigrationColumn(change.NewColumn, target, dialect), renderSchemaArg("schema", change.Table.Schema))
+		case ChangeDropColumn:
+			cb.Line("op.drop_column('%s', '%s'%s)", change.Table.Name, change.OldColumn.Name, renderSchemaArg("schema", change.Table.Schema))
+		case ChangeRenameColumn:
+			cb.Line("op.alter_column('%s', '%s', new_column_name='%s'%s)", change.Table.Name, change.OldColumn.Name, change.NewColumn.Name, renderSchemaArg("schema", change.Table.Schema))
+		case ChangeAlterColumn:
+			args := []string{fmt.Sprintf("'%s'", change.Table.Name), fmt.Sprintf("'%s'", change.NewColumn.Name)}
+			if change.OldColumn.Type != change.NewColumn.Type || change.OldColumn.EnumName != change.NewColumn.EnumName {
+				args = append(args,
+					"type_="+renderMigrationType(change.NewColumn, target, dialect),
+					"existing_type="+renderMigrationType(change.OldColumn, source, dialect),
+				)
+			}
+			if change.OldColumn.Nullable != change.NewColumn.Nullable {
+				args = append(args,
+					"nullable="+pythonBool(change.NewColumn.Nullable),
+					"existing_nullable="+pythonBool(change.OldColumn.Nullable),
+				)
+			}
+			if change.Table.Schema != "" {
+				args = append(args, "schema="+quotePythonString(change.Table.Schema))
+			}
+			cb.Line("op.alter_column(%s)", strings.Join(args, ", "))
+		case ChangeAddForeignKey:
+			cb.Line("op.create_foreign_key('%s', '%s', '%s', ['%s'], ['%s']%s%s)",
+				change.ForeignKey.Name, change.Table.Name, change.ForeignKey.RefTable, change.ForeignKey.Column, change.ForeignKey.RefColumn,
+				renderSchemaArg("source_schema", change.Table.Schema), renderSchemaArg("referent_schema", change.ForeignKey.RefSchema))
+		case ChangeDropForeignKey:
+			cb.Line("op.drop_constraint('%s', '%s', type_='foreignkey'%s)", change.ForeignKey.Name, change.Table.Name, renderSchemaArg("schema", change.Table.Schema))
+		case ChangeAddUnique:
+			cb.Line("op.create_unique_constraint('%s', '%s', [%s]%s)", change.Unique.Name, change.Table.Name, quoteColumnList(change.Unique.Columns), renderSchemaArg("schema", change.Table.Schema))
+		case ChangeDropUnique:
+			cb.Line("op.drop_constraint('%s', '%s', type_='unique'%s)", change.Unique.Name, change.Table.Name, renderSchemaArg("schema", change.Table.Schema))
+		case ChangeAddIndex:
+			cb.Line("op.create_index('%s', '%s', [%s]%s)", change.Index.Name, change.Table.Name, quoteColumnList(change.Index.Columns), renderSchemaArg("schema", change.Table.Schema))
+		case ChangeDropIndex:
+			cb.Line("op.drop_index('%s', table_name='%s'%s)", change.Index.Name, change.Table.Name, renderSchemaArg("schema", change.Table.Schema))
+		case ChangeCreateEnum:
+			cb.Line("sa.Enum(%s, name='%s').create(op.get_bind(), checkfirst=True)", quoteColumnList(change.Enum.Members), change.Enum.TypeName)
+		case ChangeDropEnum:
+			cb.Line("sa.Enum(name='%s').drop(op.get_bind(), checkfirst=True)", change.Enum.TypeName)
+		case ChangeAddEnumMember, ChangeDropEnumMember:
+			if !renderEnumMemberChange(cb, change, target, source, dialect, rewrittenEnums) {
+				continue
+			}
+		case ChangeCreateView:
+			cb.Line(`op.execute("""`)
+			for _, line := range strings.Split(renderViewSQL(change.View, dialect), "\n") {
+				cb.Line("%s", line)
+			}
+			cb.Line(`""")`)
+		case ChangeDropView:
+			cb.Line(`op.execute("""DROP VIEW %s""")`, quoteSQLTableName(change.View.Schema, change.View.Name, dialect))
+		}
+		rendered++
+	}
+
+	if rendered == 0 {
+		cb.Line("pass")
+	}
+}
+
+// describeSchemaChange returns a short human-readable description of a change
+func describeSchemaChange(change SchemaChange) string {
+	switch change.Kind {
+	case ChangeDropTable:
+		return fmt.Sprintf("drops table %s and all of its data", change.Table.Name)
+	case ChangeDropColumn:
+		return fmt.Sprintf("drops column %s.%s and its data", change.Table.Name, change.OldColumn.Name)
+	case ChangeAddColumn:
+		return fmt.Sprintf("adds non-nullable column %s.%s, existing rows need a value", change.Table.Name, change.NewColumn.Name)
+	case ChangeRenameColumn:
+		return fmt.Sprintf("detected %s.%s -> %s as a rename, verify it is not a drop and add", change.Table.Name, change.OldColumn.Name, change.NewColumn.Name)
+	case ChangeAlterColumn:
+		if change.OldColumn.Type != change.NewColumn.Type || change.OldColumn.EnumName != change.NewColumn.EnumName {
+			return fmt.Sprintf("changes type of %s.%s, existing values may not convert", change.Table.Name, change.NewColumn.Name)
+		}
+		return fmt.Sprintf("makes %s.%s non-nullable, existing NULL values will fail", change.Table.Name, change.NewColumn.Name)
+	case ChangeAddUnique:
+		return fmt.Sprintf("adds unique constraint %s, existing duplicates will fail", change.Unique.Name)
+	case ChangeDropEnum:
+		return fmt.Sprintf("drops enum type %s", change.Enum.TypeName)
+	case ChangeDropEnumMember:
+		return fmt.Sprintf("removes member %s from enum type %s", change.Member, change.Enum.TypeName)
+	}
+	return string(change.Kind)
+}
+
+// renderEnumMemberChange renders an added or removed enum member and reports whether it
+// rendered any statement. PostgreSQL adds members to the enum type in place and removes
+// them by swapping in a recreated type; the other dialects store enums inline, so the
+// columns using the enum are altered to the new member list instead. Each enum is
+// rewritten at most once per migration.
+func renderEnumMemberChange(cb *formatdef.ContentBuilder, change SchemaChange, target *formatdef.Schema, source *formatdef.Schema, dialect string, rewritten map[string]bool) bool {
+	if dialect == typemap.DialectPostgreSQL && change.Kind == ChangeAddEnumMember {
+		// ADD VALUE cannot run inside a transaction block before PostgreSQL 12
+		cb.Line("with op.get_context().autocommit_block():")
+		cb.Indent()
+		cb.Line("op.execute(\"ALTER TYPE %s ADD VALUE IF NOT EXISTS '%s'\")", change.Enum.TypeName, change.Member)
+		cb.Dedent()
+		return true
+	}
+
+	if rewritten[change.Enum.Name] {
+		cb.Line("# enum type %s is rewritten above", change.Enum.TypeName)
+		return false
+	}
+	rewritten[change.Enum.Name] = true
+
+	newEnum, exists := target.GetEnum(change.Enum.Name)
+	if !exists {
+		newEnum = change.Enum
+	}
+	oldEnum, exists := source.GetEnum(change.Enum.Name)
+	if !exists {
+		oldEnum = change.Enum
+	}
+	tables := getEnumTables(target, change.Enum.Name)
+
+	if dialect == typemap.DialectPostgreSQL {
+		typeName := quoteSQLIdentifier(newEnum.TypeName, dialect)
+		oldTypeName := quoteSQLIdentifier(newEnum.TypeName+"_old", dialect)
+		cb.Line(`op.execute("""ALTER TYPE %s RENAME TO %s""")`, typeName, oldTypeName)
+		cb.Line("sa.Enum(%s, name='%s').create(op.get_bind())", quoteColumnList(newEnum.Members), newEnum.TypeName)
+		for _, table := range tables {
+			for _, column := range table.Columns {
+				columnName := quoteSQLIdentifier(column.Name, dialect)
+				cb.Line(`op.execute("""ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::text::%s""")`,
+					quoteSQLTableName(table.Schema, table.Name, dialect), columnName, typeName, columnName, typeName)
+			}
+		}
+		cb.Line(`op.execute("""DROP TYPE %s""")`, oldTypeName)
+		return true
+	}
+
+	if len(tables) == 0 {
+		cb.Line("# enum type %s is stored inline and no column uses it", newEnum.TypeName)
+		return false
+	}
+	for _, table := range tables {
+		cb.Line("with op.batch_alter_table('%s'%s) as batch_op:", table.Name, renderSchemaArg("schema", table.Schema))
+		cb.Indent()
+		for _, column := range table.Columns {
+			cb.Line("batch_op.alter_column('%s', type_=%s, existing_type=%s, existing_nullable=%s)",
+				column.Name, renderMigrationEnumType(newEnum, dialect), renderMigrationEnumType(oldEnum, dialect), pythonBool(column.Nullable))
+		}
+		cb.Dedent()
+	}
+	return true
+}
+
+// getEnumTables returns the tables storing an enum, each reduced to the columns using it
+func getEnumTables(schema *formatdef.Schema, enumName string) []formatdef.Table {
+	var tables []formatdef.Table
+	for _, table := range schema.Tables {
+		var columns []formatdef.Column
+		for _, column := range table.Columns {
+			if column.EnumName == enumName {
+				columns = append(columns, column)
+			}
+		}
+		if len(columns) > 0 {
+			table.Columns = columns
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// renderMigrationColumn renders an sa.Column(...) expression for a migration
+func renderMigrationColumn(column formatdef.Column, schema *formatdef.Schema, dialect string) string {
+	args := []string{fmt.Sprintf("'%s'", column.Name), renderMigrationType(column, schema, dialect)}
+	if column.PrimaryKey {
+		args = append(args, "primary_key=True")
+		if column.AutoIncrement {
+			args = append(args, "autoincrement=True")
+		}
+	} else {
+		args = append(args, "nullable="+pythonBool(column.Nullable))
+	}
+	return fmt.Sprintf("sa.Column(%s)", strings.Join(args, ", "))
+}
+
+// renderMigrationType renders the sa type of a column for a migration
+func renderMigrationType(column formatdef.Column, schema *formatdef.Schema, dialect string) string {
+	if column.EnumName != "" {
+		enum, exists := schema.GetEnum(column.EnumName)
+		if !exists {
+			enum = formatdef.EnumDef{Name: column.EnumName, TypeName: strings.ToLower(column.EnumName)}
+		}
+		return renderMigrationEnumType(enum, dialect)
+	}
+	if column.Type == "JSONB" {
+		// Matches the models, which store JSONB only on PostgreSQL
+		return "sa.JSON().with_variant(postgresql.JSONB(), 'postgresql')"
+	}
+	return fmt.Sprintf("sa.%s()", column.Type)
+}
+
+// renderMigrationEnumType renders the type of an enum column. PostgreSQL enum types are
+// created once by their own create_enum operation, so columns must not create them again.
+func renderMigrationEnumType(enum formatdef.EnumDef, dialect string) string {
+	args := quoteColumnList(enum.Members)
+	if args != "" {
+		args += ", "
+	}
+	if dialect == typemap.DialectPostgreSQL {
+		return fmt.Sprintf("postgresql.ENUM(%sname='%s', create_type=False)", args, enum.TypeName)
+	}
+	return fmt.Sprintf("sa.Enum(%sname='%s')", args, enum.TypeName)
+}
+
+// renderSchemaArg renders a trailing schema keyword argument, or "" without a schema
+func renderSchemaArg(keyword string, schema string) string {
+	if schema == "" {
+		return ""
+	}
+	return fmt.Sprintf(", %s=%s", keyword, quotePythonString(schema))
+}
+
+// quoteColumnList renders a comma separated list of quoted names
+func quoteColumnList(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, fmt.Sprintf("'%s'", name))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// pythonBool renders a Go bool as a Python literal
+func pythonBool(value bool) string {
+	if value {
+		return "True"
+	}
+	return "False"
+}
+
+// getMigrationMessage returns the configured revision message
+func getMigrationMessage(config cfg.MigrationConfig) string {
+	if config.Message != "" {
+		return config.Message
+	}
+	return defaultMigrationMessage
+}
+
+// toMigrationSlug converts a revision message to a file name slug
+func toMigrationSlug(message string) string {
+	var result []rune
+	for _, r := range strings.ToLower(message) {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9':
+			result = append(result, r)
+		case len(result) > 0 && result[len(result)-1] != '_':
+			result = append(result, '_')
+		}
+	}
+	return strings.Trim(string(result), "_")
+}
@@ -159,27 +159,54 @@ func CompileAllModels(config MorpheCompileConfig, r *registry.Registry, writer *
 
 	// Process each model in the registry
 	for modelName, model := range r.GetAllModels() {
+		// Skipped models are mapped by code managed elsewhere
+		if getTableConfig(modelName, config.FormatConfig).Skip {
+			continue
+		}
+
 		// Compile the model
 		compiledModel, err := CompileModel(model, r)
 		if err != nil {
 			return fmt.Errorf("failed to compile model %s: %w", modelName, err)
 		}
 
+		// Compile the table layout behind the model
+		table, err := CompileTable(model, config.FormatConfig, r)
+		if err != nil {
+			return fmt.Errorf("failed to compile table for model %s: %w", modelName, err)
+		}
+
 		// Generate the content for this model
-		content := generateModelContent(compiledModel, model, config.FormatConfig, config.MorpheConfig, r)
+		content := generateModelContent(compiledModel, table, model, config.FormatConfig, config.MorpheConfig, r)
 		modelContents[modelName] = content
 	}
 
+	// Entities served by a view get a read-only class mapped over it
+	views, err := CompileViews(config, r)
+	if err != nil {
+		return err
+	}
+	for i := range views {
+		entity, err := r.GetEntity(views[i].EntityName)
+		if err != nil {
+			return fmt.Errorf("view entity not found: %s", views[i].EntityName)
+		}
+		relationships, err := compileViewRelationships(entity, &views[i], config.FormatConfig, r)
+		if err != nil {
+			return fmt.Errorf("failed to compile view relationships for entity %s: %w", entity.Name, err)
+		}
+		modelContents[getViewClassName(entity.Name)] = generateViewModelContent(&views[i], relationships, config.FormatConfig, r)
+	}
+
 	// Write all model contents
 	return writer.WriteAllModels(modelContents)
 }
 
 // generateModelContent generates SQLAlchemy model
-func generateModelContent(model *formatdef.Struct, yamlModel yaml.Model, config SQLAlchemyConfig, morpheConfig cfg.MorpheConfig, r *registry.Registry) []byte {
+func generateModelContent(model *formatdef.Struct, table *formatdef.Table, yamlModel yaml.Model, config SQLAlchemyConfig, morpheConfig cfg.MorpheConfig, r *registry.Registry) []byte {
 	cb := formatdef.NewContentBuilder("    ")
 
 	// Add header comment
-	cb.Line("# Code generated by Morphe")
 	cb.Line("# SQLAlchemy model definition")
 	if config.UseDeclarative {
 		cb.Line("# Note: This requires a Base class defined as:")
@@ -195,6 +222,9 @@ func generateModelContent(model *formatdef.Struct, yamlModel yaml.Model, config
 	if config.UseDeclarative {
 		imports.AddSQLAlchemy("Column", "Integer", "String", "Text", "Float", "Boolean", "DateTime", "Date", "ForeignKey", "JSON")
 		imports.AddSQLAlchemy("relationship")
+		if len(table.Uniques) > 0 {
+			imports.AddSQLAlchemy("UniqueConstraint")
+		}
 		// Note: Base needs to be imported from a module that defines it
 		// In a typical SQLAlchemy project, this would be defined as:
 		// Base = declarative_base()
@@ -245,6 +275,24 @@ func generateModelContent(model *formatdef.Struct, yamlModel yaml.Model, config
 		imports.TrackFieldType(typeName)
 	}
 
+	// Scan table columns for enums and composites not visible in the model fields
+	hasComposite := false
+	for _, column := range table.Columns {
+		if column.EnumName != "" {
+			imports.TrackFieldType(column.EnumName)
+			hasEnumField = true
+		}
+		if column.Composite != "" {
+			hasComposite = true
+		}
+		if column.Structure != "" && config.UseDeclarative {
+			imports.AddFrom(".base", "StructureJSON")
+		}
+	}
+	if hasComposite && config.UseDeclarative {
+		imports.AddFrom("sqlalchemy.orm", "composite")
+	}
+
 	// We always need Optional for navigation properties
 	if config.AddTypeHints {
 		imports.AddTyping("Optional")
@@ -260,6 +308,27 @@ func generateModelContent(model *formatdef.Struct, yamlModel yaml.Model, config
 		imports.AddSQLAlchemy("Enum")
 	}
 
+	// Identity helpers look rows up by the model identifiers, in the session style of the repositories
+	var lookups []identifierLookup
+	isAsync := getLazyLoadingStyle(morpheConfig.Entities) == "async"
+	if morpheConfig.Models.IdentityHelpers && config.UseDeclarative && len(yamlModel.Identifiers) > 0 {
+		lookups = getIdentifierLookups(model.Fields, getModelIdentifierFields(yamlModel), config)
+		imports.AddSQLAlchemy("select")
+		imports.AddTyping("Optional", "Tuple")
+		if isAsync {
+			imports.AddTypeCheckingImport("from sqlalchemy.ext.asyncio import AsyncSession")
+		} else {
+			imports.AddTypeCheckingImport("from sqlalchemy.orm import Session")
+		}
+		for _, lookup := range lookups {
+			for _, param := range lookup.Params {
+				if _, typeName, hasType := strings.Cut(param, ": "); hasType {
+					imports.TrackFieldType(typeName)
+				}
+			}
+		}
+	}
+
 	// Generate imports
 	imports.Generate(cb)
 	cb.Line("")
@@ -269,7 +338,22 @@ func generateModelContent(model *formatdef.Struct, yamlModel yaml.Model, config
 		cb.Line("class %s(Base):", model.Name)
 		cb.Indent()
 		// Add table name
-		cb.Line("__tablename__ = '%s%s%s'", config.TableNamePrefix, formatdef.ToSnakeCase(model.Name), config.TableNameSuffix)
+		cb.Line("__tablename__ = '%s'", table.Name)
+		tableOptions := renderTableOptions(table)
+		if len(table.Uniques) > 0 {
+			cb.Line("__table_args__ = (")
+			cb.Indent()
+			for _, unique := range table.Uniques {
+				cb.Line("%s,", renderUniqueConstraint(unique))
+			}
+			if tableOptions != "" {
+				cb.Line("%s,", tableOptions)
+			}
+			cb.Dedent()
+			cb.Line(")")
+		} else if tableOptions != "" {
+			cb.Line("__table_args__ = %s", tableOptions)
+		}
 		cb.Line("")
 	} else {
 		cb.Line("class %s:", model.Name)
@@ -283,99 +367,24 @@ func generateModelContent(model *formatdef.Struct, yamlModel yaml.Model, config
 		cb.Line("pass")
 	} else {
 		// Add fields
-		for _, field := range model.Fields {
-			// Skip navigation properties
-			if strings.HasPrefix(field.Name, "_nav_") {
-				continue
+		if config.UseDeclarative {
+			// Generate SQLAlchemy column definitions from the table layout
+			for _, column := range table.Columns {
+				cb.Line("%s = %s", column.Attribute, renderColumn(column, table, config))
 			}
-
-			fieldName := SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name))
-			fieldType := field.Type.GetName()
-
-			// Generate SQLAlchemy column definition
-			if config.UseDeclarative {
-				// Determine if this is a primary key
-				isPrimaryKey := false
-				if primaryId, exists := yamlModel.Identifiers["primary"]; exists {
-					for _, idField := range primaryId.Fields {
-						if idField == field.Name {
-							isPrimaryKey = true
-							break
-						}
-					}
+			for _, line := range renderComposites(table) {
+				cb.Line("%s", line)
+			}
+		} else {
+			for _, field := range model.Fields {
+				// Skip navigation properties
+				if strings.HasPrefix(field.Name, "_nav_") {
+					continue
 				}
 
-				// Check if this is a foreign key
-				isForeignKey := strings.HasSuffix(fieldName, "_id") && len(fieldName) > 3
-
-				// Check if field is nullable
-				nullable := !isPrimaryKey && field.Type.IsNullable()
-
-				// For foreign keys
-				if isForeignKey {
-					// Try to determine the referenced table
-					refFieldName := fieldName[:len(fieldName)-3] // Remove _id suffix
-					refTableName := formatdef.ToSnakeCase(refFieldName)
-					nullableStr := "False"
-					if nullable {
-						nullableStr = "True"
-					}
-					cb.Line("%s = Column(Integer, ForeignKey('%s.id'), nullable=%s)", fieldName, refTableName, nullableStr)
-				} else if strings.HasSuffix(fieldName, "_type") {
-					// Polymorphic type field
-					nullableStr := "False"
-					if nullable {
-						nullableStr = "True"
-					}
-					cb.Line("%s = Column(String, nullable=%s)", fieldName, nullableStr)
-				} else {
-					// Regular column
-					sqlType := mapFieldTypeToSQLAlchemy(field.Type)
-
-					// Check if this is an enum field
-					if basicType, ok := field.Type.(formatdef.BasicType); ok {
-						innerType := extractInnerType(basicType.Name)
-						if innerType != "" && resolveFieldType(innerType, r) == "enum" {
-							// It's an enum field - use the enum type directly
-							if isPrimaryKey {
-								cb.Line("%s = Column(Enum(%s), primary_key=True)", fieldName, innerType)
-							} else {
-								nullableStr := "False"
-								if nullable {
-									nullableStr = "True"
-								}
-								cb.Line("%s = Column(Enum(%s), nullable=%s)", fieldName, innerType, nullableStr)
-							}
-							continue
-						}
-					}
-
-					// Check if it's an auto-increment field by looking at the original yaml model
-					isAutoIncrement := false
-					for origFieldName, origField := range yamlModel.Fields {
-						if origFieldName == field.Name && origField.Type == yaml.ModelFieldTypeAutoIncrement {
-							isAutoIncrement = true
-							break
-						}
-					}
-
-					if isPrimaryKey {
-						if isAutoIncrement {
-							cb.Line("%s = Column(%s, primary_key=True, autoincrement=True)", fieldName, sqlType)
-						} else {
-							cb.Line("%s = Column(%s, primary_key=True)", fieldName, sqlType)
-						}
-					} else {
-						nullableStr := "False"
-						if nullable {
-							nullableStr = "True"
-						}
-						cb.Line("%s = Column(%s, nullable=%s)", fieldName, sqlType, nullableStr)
-					}
-				}
-			} else {
 				// Non-declarative style (fallback)
-				cb.Line("%s: %s", fieldName, fieldType)
+				fieldName := SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name))
+				cb.Line("%s: %s", fieldName, field.Type.GetName())
 			}
 		}
 
@@ -390,7 +399,7 @@ func generateModelContent(model *formatdef.Struct, yamlModel yaml.Model, config
 
 				// Remove _nav_ prefix to get the actual relationship name
 				relName := strings.TrimPrefix(field.Name, "_nav_")
-				fieldName := SanitizePythonIdentifier(formatdef.ToSnakeCase(relName))
+				fieldName := getRelationshipName(relName, string(yamlModel.Related[relName].Type), config)
 				fieldType := field.Type.GetName()
 
 				// Skip if this is a polymorphic relationship with corresponding type/id fields
@@ -414,26 +423,96 @@ func generateModelContent(model *formatdef.Struct, yamlModel yaml.Model, config
 					targetModel := fieldType[5 : len(fieldType)-1] // Remove List[ and ]
 					targetModel = strings.Trim(targetModel, "'\"") // Remove quotes if any
 					cb.Line("%s = relationship(\"%s\", back_populates=\"%s\")",
-						fieldName, targetModel, formatdef.ToSnakeCase(model.Name))
+						fieldName, targetModel, getBackPopulatesName(model.Name, targetModel, config, r))
 				} else if strings.Contains(fieldType, "Union[") {
 					// Polymorphic union type - skip for now
 					continue
 				} else {
 					// One relationship
 					targetModel := strings.Trim(fieldType, "'\"") // Remove quotes if any
-					cb.Line("%s = relationship(\"%s\", back_populates=\"%s\")",
-						fieldName, targetModel, formatdef.ToSnakeCase(model.Name))
+					backPopulates := getBackPopulatesName(model.Name, targetModel, config, r)
+					// The foreign key of a HasOne lives on the related table, which SQLAlchemy maps as a list unless told otherwise
+					if yamlops.IsRelationHas(string(yamlModel.Related[relName].Type)) {
+						cb.Line("%s = relationship(\"%s\", back_populates=\"%s\", uselist=False)", fieldName, targetModel, backPopulates)
+					} else {
+						cb.Line("%s = relationship(\"%s\", back_populates=\"%s\")", fieldName, targetModel, backPopulates)
+					}
 				}
 			}
 		}
 
 	}
 
+	if len(lookups) > 0 {
+		renderModelIdentityHelpers(cb, model.Name, lookups, isAsync)
+	}
+	cb.CustomRegion()
+
 	cb.Dedent() // End of class body
 
 	return cb.Build()
 }
 
+// renderModelIdentityHelpers renders identity_key() and a get_by_ classmethod for each model identifier
+func renderModelIdentityHelpers(cb *formatdef.ContentBuilder, modelName string, lookups []identifierLookup, isAsync bool) {
+	if lookups[0].IsPrimary {
+		var values []string
+		for _, attribute := range lookups[0].Attributes {
+			values = append(values, "self."+attribute)
+		}
+		cb.Line("")
+		cb.Line("def identity_key(self) -> Tuple:")
+		cb.Indent()
+		cb.Line(`"""Return the primary identifier values of the row."""`)
+		cb.Line("return (%s,)", strings.Join(values, ", "))
+		cb.Dedent()
+	}
+
+	sessionType := "'Session'"
+	def := "def"
+	execute := "session.execute(query)"
+	if isAsync {
+		sessionType = "'AsyncSession'"
+		def = "async def"
+		execute = "await session.execute(query)"
+	}
+	for _, lookup := range lookups {
+		var conditions []string
+		for _, attribute := range lookup.Attributes {
+			conditions = append(conditions, fmt.Sprintf("cls.%s == %s", attribute, attribute))
+		}
+		cb.Line("")
+		cb.Line("@classmethod")
+		cb.Line("%s get_by_%s(cls, session: %s, %s) -> Optional['%s']:", def, lookup.MethodSuffix, sessionType, strings.Join(lookup.Params, ", "), modelName)
+		cb.Indent()
+		cb.Line(`"""Get a %s by its %s."""`, modelName, lookup.Description)
+		cb.Line("query = select(cls).where(%s)", strings.Join(conditions, ", "))
+		cb.Line("result = %s", execute)
+		cb.Line("return result.scalars().first()")
+		cb.Dedent()
+	}
+}
+
+// getBackPopulatesName returns the relationship of the target model pointing back at a model,
+// or the model's name if the target has no such relation
+func getBackPopulatesName(modelName string, targetModelName string, config SQLAlchemyConfig, r *registry.Registry) string {
+	targetModel, err := r.GetModel(targetModelName)
+	if err != nil {
+		return getAttributeName(modelName)
+	}
+	var backNames []string
+	for name, relation := range targetModel.Related {
+		if !yamlops.IsRelationPoly(string(relation.Type)) && yamlops.GetRelationTargetName(name, relation.Aliased) == modelName {
+			backNames = append(backNames, name)
+		}
+	}
+	if len(backNames) == 0 {
+		return getAttributeName(modelName)
+	}
+	sort.Strings(backNames)
+	return getRelationshipName(backNames[0], string(targetModel.Related[backNames[0]].Type), config)
+}
+
 // getSQLAlchemyType converts a Python type to SQLAlchemy column type
 func getSQLAlchemyType(pythonType string) string {
 	// Remove Optional wrapper if present
@@ -475,3 +554,218 @@ func mapFieldTypeToSQLAlchemy(fieldType formatdef.Type) string {
 	}
 	return getSQLAlchemyType(typeName)
 }
+
+// renderColumn renders the SQLAlchemy Column(...) expression for a table column
+func renderColumn(column formatdef.Column, table *formatdef.Table, config SQLAlchemyConfig) string {
+	// The column name is always explicit so the attribute can be renamed freely
+	args := []string{quotePythonString(column.Name), column.Type}
+	if column.EnumName != "" {
+		args[1] = fmt.Sprintf("Enum(%s)", column.EnumName)
+	}
+	if column.Structure != "" {
+		args[1] = renderStructureJSONType(column, config)
+	}
+
+	if fk, isForeignKey := table.GetForeignKey(column.Name); isForeignKey {
+		// link_to_name resolves the target by column name instead of attribute key
+		args = append(args, fmt.Sprintf("ForeignKey('%s.%s', link_to_name=True)", fk.QualifiedRefTable(), fk.RefColumn))
+	}
+
+	for _, index := range table.Indexes {
+		if len(index.Columns) == 1 && index.Columns[0] == column.Name {
+			args = append(args, "index=True")
+			break
+		}
+	}
+
+	if column.PrimaryKey {
+		args = append(args, "primary_key=True")
+		if column.AutoIncrement {
+			args = append(args, "autoincrement=True")
+		}
+	} else if column.Nullable {
+		args = append(args, "nullable=True")
+	} else {
+		args = append(args, "nullable=False")
+	}
+
+	return fmt.Sprintf("Column(%s)", strings.Join(args, ", "))
+}
+
+// renderStructureJSONType renders the column type of a structure stored as a JSON document
+func renderStructureJSONType(column formatdef.Column, config SQLAlchemyConfig) string {
+	jsonType := fmt.Sprintf("StructureJSON(%s)", column.Structure)
+	if column.Type == "JSONB" {
+		jsonType = fmt.Sprintf("StructureJSON(%s, binary=True)", column.Structure)
+	}
+	if config.MutableStructures {
+		// Associates the structure's in-place change events with this column
+		return fmt.Sprintf("%s.as_mutable(%s)", column.Structure, jsonType)
+	}
+	return jsonType
+}
+
+// renderComposites renders the composite() mappings of structure-typed fields
+func renderComposites(table *formatdef.Table) []string {
+	var lines []string
+	var fieldOrder []string
+	attributes := make(map[string][]string)
+	structures := make(map[string]string)
+	for _, column := range table.Columns {
+		if column.Composite == "" {
+			continue
+		}
+		if _, exists := attributes[column.Field]; !exists {
+			fieldOrder = append(fieldOrder, column.Field)
+			structures[column.Field] = column.Composite
+		}
+		attributes[column.Field] = append(attributes[column.Field], column.Attribute)
+	}
+
+	if len(fieldOrder) > 0 {
+		lines = append(lines, "")
+	}
+	for _, field := range fieldOrder {
+		lines = append(lines, fmt.Sprintf("%s = composite(%s, %s)",
+			getAttributeName(field), structures[field], strings.Join(attributes[field], ", ")))
+	}
+	return lines
+}
+
+// renderTableOptions renders the keyword dict of __table_args__, or "" if there are no options
+func renderTableOptions(table *formatdef.Table) string {
+	var options []string
+	if table.Schema != "" {
+		options = append(options, fmt.Sprintf("'schema': %s", quotePythonString(table.Schema)))
+	}
+	if table.Comment != "" {
+		options = append(options, fmt.Sprintf("'comment': %s", quotePythonString(table.Comment)))
+	}
+	if len(options) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(options, ", ") + "}"
+}
+
+// renderUniqueConstraint renders the SQLAlchemy UniqueConstraint(...) expression.
+// Columns are declared with explicit names, which key them in Table.c, so the constraint refers to column names.
+func renderUniqueConstraint(unique formatdef.UniqueConstraint) string {
+	var args []string
+	for _, columnName := range unique.Columns {
+		args = append(args, quotePythonString(columnName))
+	}
+	args = append(args, fmt.Sprintf("name=%s", quotePythonString(unique.Name)))
+	return fmt.Sprintf("UniqueConstraint(%s)", strings.Join(args, ", "))
+}
+
+// generateBaseContent generates base.py with the declarative base and its naming convention
+func generateBaseContent(config SQLAlchemyConfig) []byte {
+	convention := getNamingConvention(config)
+
+	cb := formatdef.NewContentBuilder("    ")
+	cb.Line("# SQLAlchemy Base definition")
+	cb.Line("")
+	usesStructureJSON := usesStructureJSONStorage(config)
+	if usesStructureJSON {
+		cb.Line("from sqlalchemy import JSON, MetaData")
+		cb.Line("from sqlalchemy.dialects.postgresql import JSONB")
+		cb.Line("from sqlalchemy.types import TypeDecorator")
+	} else {
+		cb.Line("from sqlalchemy import MetaData")
+	}
+	cb.Line("from sqlalchemy.ext.declarative import declarative_base")
+	cb.Line("")
+	cb.Line("# Constraint naming convention, keeps constraint names stable for Alembic")
+	cb.Line("NAMING_CONVENTION = {")
+	cb.Indent()
+	for _, kind := range getSortedConventionKinds(convention) {
+		cb.Line("%q: %q,", kind, convention[kind])
+	}
+	cb.Dedent()
+	cb.Line("}")
+	cb.Line("")
+	cb.Line("metadata = MetaData(naming_convention=NAMING_CONVENTION)")
+	cb.Line("")
+	cb.Line("# Create the declarative base that all models will inherit from")
+	cb.Line("Base = declarative_base(metadata=metadata)")
+	cb.Line("")
+	cb.Line("# You can customize the Base class here if needed")
+	cb.Line("# For example:")
+	cb.Line("# Base.query = db.session.query_property()")
+	cb.Line("")
+
+	if usesStructureJSON {
+		cb.Line("")
+		cb.Line("class StructureJSON(TypeDecorator):")
+		cb.Indent()
+		cb.Line(`"""Stores a structure as a JSON document and rebuilds it on load."""`)
+		cb.Line("")
+		cb.Line("impl = JSON")
+		cb.Line("cache_ok = True")
+		cb.Line("")
+		cb.Line("def __init__(self, structure_class, binary=False):")
+		cb.Indent()
+		cb.Line("super().__init__()")
+		cb.Line("self.structure_class = structure_class")
+		cb.Line("self.binary = binary")
+		cb.Dedent()
+		cb.Line("")
+		cb.Line("@property")
+		cb.Line("def python_type(self):")
+		cb.Indent()
+		cb.Line("return self.structure_class")
+		cb.Dedent()
+		cb.Line("")
+		cb.Line("def load_dialect_impl(self, dialect):")
+		cb.Indent()
+		cb.Line("# JSONB only exists in PostgreSQL, other dialects store plain JSON")
+		cb.Line("if self.binary and dialect.name == 'postgresql':")
+		cb.Indent()
+		cb.Line("return dialect.type_descriptor(JSONB())")
+		cb.Dedent()
+		cb.Line("return dialect.type_descriptor(JSON())")
+		cb.Dedent()
+		cb.Line("")
+		cb.Line("def process_bind_param(self, value, dialect):")
+		cb.Indent()
+		cb.Line("if value is None or isinstance(value, dict):")
+		cb.Indent()
+		cb.Line("return value")
+		cb.Dedent()
+		cb.Line("return value.to_dict()")
+		cb.Dedent()
+		cb.Line("")
+		cb.Line("def process_result_value(self, value, dialect):")
+		cb.Indent()
+		cb.Line("if value is None:")
+		cb.Indent()
+		cb.Line("return None")
+		cb.Dedent()
+		cb.Line("return self.structure_class.from_dict(value)")
+		cb.Dedent()
+		cb.Dedent()
+	}
+
+	return cb.Build()
+}
+
+// usesStructureJSONStorage reports whether any structure field may be stored as a JSON document
+func usesStructureJSONStorage(config SQLAlchemyConfig) bool {
+	if config.StructureStorage == StructureStorageJSON || config.StructureStorage == StructureStorageJSONB {
+		return true
+	}
+	for _, fields := range config.StructureStorageFields {
+		for _, storage := range fields {
+			if storage == StructureStorageJSON || storage == StructureStorageJSONB {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quotePythonString renders a single-quoted Python string literal
+func quotePythonString(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`).Replace(value)
+	return "'" + escaped + "'"
+}
@@ -0,0 +1,270 @@
+package compile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+)
+
+// pythonStructureJSONPrelude stubs the SQLAlchemy imports of base.py and the mutable structures, so the
+// StructureJSON type decorator can be run without SQLAlchemy installed
+const pythonStructureJSONPrelude = `
+import sys
+import types
+
+def stub_module(name, **attributes):
+    module = types.ModuleType(name)
+    module.__dict__.update(attributes)
+    sys.modules[name] = module
+    return module
+
+
+class JSON:
+    pass
+
+
+class JSONB:
+    pass
+
+
+class TypeDecorator:
+    def __init__(self):
+        pass
+
+
+class Mutable:
+    def changed(self):
+        self.__dict__.setdefault('changes', 0)
+        self.__dict__['changes'] += 1
+
+    @classmethod
+    def coerce(cls, key, value):
+        raise ValueError(f'{key} can not be coerced')
+
+
+class Dialect:
+    def __init__(self, name):
+        self.name = name
+
+    def type_descriptor(self, type_):
+        return type_
+
+
+stub_module('sqlalchemy', JSON=JSON, MetaData=lambda **kwargs: None)
+stub_module('sqlalchemy.types', TypeDecorator=TypeDecorator)
+stub_module('sqlalchemy.dialects')
+stub_module('sqlalchemy.dialects.postgresql', JSONB=JSONB)
+stub_module('sqlalchemy.ext')
+stub_module('sqlalchemy.ext.declarative', declarative_base=lambda **kwargs: object)
+stub_module('sqlalchemy.ext.mutable', Mutable=Mutable)
+
+from working.base import StructureJSON
+from working.structures.address import Address
+
+address = Address(city='Berlin', house_nr='1', street='Main', zip_code='10115')
+document = {'city': 'Berlin', 'house_nr': '1', 'street': 'Main', 'zip_code': '10115'}
+`
+
+type CompileModelsTestSuite struct {
+	generatedCodeFixture
+}
+
+func TestCompileModelsTestSuite(t *testing.T) {
+	suite.Run(t, new(CompileModelsTestSuite))
+}
+
+func (suite *CompileModelsTestSuite) SetupTest() {
+	suite.setupFixture("minimal", pythonStructureJSONPrelude)
+}
+
+func (suite *CompileModelsTestSuite) TearDownTest() {
+	suite.tearDownFixture()
+}
+
+func (suite *CompileModelsTestSuite) TestStructureStorage_Composite() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+
+	person := suite.readOutput("models/person.py")
+	suite.Contains(person, "home_address = composite(Address")
+	suite.NotContains(person, "StructureJSON")
+	suite.NotContains(suite.readOutput("base.py"), "StructureJSON")
+	// Only structures mapped with composite() return their column values
+	suite.Contains(suite.readOutput("structures/address.py"), "def __composite_values__(self):")
+	suite.NotContains(suite.readOutput("structures/delivery.py"), "__composite_values__")
+}
+
+func (suite *CompileModelsTestSuite) TestStructureStorage_JSON() {
+	suite.compile(compile.SQLAlchemyConfig{StructureStorage: compile.StructureStorageJSON}, cfg.MorpheConfig{})
+
+	person := suite.readOutput("models/person.py")
+	suite.Contains(person, "from .base import Base, StructureJSON")
+	suite.Contains(person, "home_address = Column('home_address', StructureJSON(Address), nullable=False)")
+	suite.NotContains(person, "composite(")
+	base := suite.readOutput("base.py")
+	suite.Contains(base, "class StructureJSON(TypeDecorator):")
+	suite.Contains(base, "from sqlalchemy.dialects.postgresql import JSONB")
+	suite.NotContains(suite.readOutput("structures/address.py"), "__composite_values__")
+
+	suite.runPython(`
+structure_json = StructureJSON(Address)
+assert structure_json.python_type is Address
+
+# Structures are stored as their dict and rebuilt on load
+assert structure_json.process_bind_param(address, None) == document
+assert structure_json.process_bind_param(document, None) is document
+assert structure_json.process_bind_param(None, None) is None
+loaded = structure_json.process_result_value(document, None)
+assert isinstance(loaded, Address) and loaded == address
+assert structure_json.process_result_value(None, None) is None
+
+# Plain JSON is used on every dialect
+assert isinstance(structure_json.load_dialect_impl(Dialect('postgresql')), JSON)
+assert isinstance(structure_json.load_dialect_impl(Dialect('sqlite')), JSON)
+`)
+}
+
+func (suite *CompileModelsTestSuite) TestStructureStorage_JSONB() {
+	suite.compile(compile.SQLAlchemyConfig{StructureStorage: compile.StructureStorageJSONB}, cfg.MorpheConfig{})
+
+	person := suite.readOutput("models/person.py")
+	suite.Contains(person, "home_address = Column('home_address', StructureJSON(Address, binary=True), nullable=False)")
+
+	suite.runPython(`
+structure_json = StructureJSON(Address, binary=True)
+
+# JSONB only exists in PostgreSQL, other dialects fall back to JSON
+assert isinstance(structure_json.load_dialect_impl(Dialect('postgresql')), JSONB)
+assert isinstance(structure_json.load_dialect_impl(Dialect('mysql')), JSON)
+assert isinstance(structure_json.load_dialect_impl(Dialect('sqlite')), JSON)
+`)
+}
+
+func (suite *CompileModelsTestSuite) TestStructureStorage_Fields() {
+	suite.compile(compile.SQLAlchemyConfig{
+		StructureStorage: compile.StructureStorageJSONB,
+		StructureStorageFields: map[string]map[string]string{
+			"Person": {"HomeAddress": compile.StructureStorageComposite},
+		},
+	}, cfg.MorpheConfig{})
+
+	// The field storage takes precedence over the default
+	person := suite.readOutput("models/person.py")
+	suite.Contains(person, "home_address = composite(Address")
+	suite.NotContains(person, "StructureJSON(Address")
+	suite.Contains(suite.readOutput("structures/address.py"), "def __composite_values__(self):")
+
+	suite.compile(compile.SQLAlchemyConfig{
+		StructureStorageFields: map[string]map[string]string{
+			"Person": {"HomeAddress": compile.StructureStorageJSON},
+		},
+	}, cfg.MorpheConfig{})
+
+	person = suite.readOutput("models/person.py")
+	suite.Contains(person, "home_address = Column('home_address', StructureJSON(Address), nullable=False)")
+	suite.Contains(suite.readOutput("base.py"), "class StructureJSON(TypeDecorator):")
+}
+
+func (suite *CompileModelsTestSuite) TestStructureStorage_MutableJSON() {
+	suite.compile(compile.SQLAlchemyConfig{
+		StructureStorage:  compile.StructureStorageJSONB,
+		MutableStructures: true,
+	}, cfg.MorpheConfig{})
+
+	person := suite.readOutput("models/person.py")
+	suite.Contains(person, "home_address = Column('home_address', Address.as_mutable(StructureJSON(Address, binary=True)), nullable=False)")
+	address := suite.readOutput("structures/address.py")
+	suite.Contains(address, "from sqlalchemy.ext.mutable import Mutable")
+	suite.Contains(address, "class Address(Mutable):")
+
+	suite.runPython(`
+# In-place changes are reported to SQLAlchemy
+changes = address.__dict__.get('changes', 0)
+address.city = 'Hamburg'
+assert address.__dict__['changes'] == changes + 1
+
+# Assigned documents are coerced into structures
+coerced = Address.coerce('home_address', document)
+assert isinstance(coerced, Address) and coerced.city == 'Berlin'
+assert Address.coerce('home_address', address) is address
+try:
+    Address.coerce('home_address', 42)
+except ValueError:
+    pass
+else:
+    raise AssertionError('expected ValueError')
+`)
+}
+
+func (suite *CompileModelsTestSuite) TestUniqueConstraint_ColumnNames() {
+	suite.compile(compile.SQLAlchemyConfig{
+		ColumnNaming: compile.ColumnNamingPreserve,
+		ColumnNames: map[string]map[string]string{
+			"Person": {"FirstName": "given_name"},
+		},
+	}, cfg.MorpheConfig{})
+
+	person := suite.readOutput("models/person.py")
+	suite.Contains(person, "first_name = Column('given_name', String, nullable=False)")
+	suite.Contains(person, "last_name = Column('LastName', String, nullable=False)")
+	// Table.c keys the columns by their explicit names, not the attribute names
+	suite.Contains(person, "UniqueConstraint('given_name', 'LastName', name='uq_person_given_name_LastName')")
+	company := suite.readOutput("models/company.py")
+	suite.Contains(company, "UniqueConstraint('Name', name='uq_company_Name')")
+	suite.NotContains(company, "UniqueConstraint('name'")
+}
+
+func (suite *CompileModelsTestSuite) TestRelationship_ToManyNaming() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+
+	// Relationships keep the relation name unless the tables are named in the plural
+	suite.Contains(suite.readOutput("models/company.py"), `person = relationship("Person", back_populates="company")`)
+	suite.Contains(suite.readOutput("models/person.py"), `company = relationship("Company", back_populates="person")`)
+	company := suite.readOutput("entities/company.py")
+	suite.Contains(company, "async def load_people(self, session: 'AsyncSession') -> List['Person']:")
+	suite.Contains(company, ".join(models.Company.person.of_type(related))")
+
+	suite.compile(compile.SQLAlchemyConfig{TableNaming: compile.TableNamingSnakePlural}, cfg.MorpheConfig{})
+
+	suite.Contains(suite.readOutput("models/company.py"), `people = relationship("Person", back_populates="company")`)
+	suite.Contains(suite.readOutput("models/person.py"), `company = relationship("Company", back_populates="people")`)
+	company = suite.readOutput("entities/company.py")
+	suite.Contains(company, "async def load_people(self, session: 'AsyncSession') -> List['Person']:")
+	suite.Contains(company, ".join(models.Company.people.of_type(related))")
+}
+
+func (suite *CompileModelsTestSuite) TestRelationship_HasOneIsScalar() {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{})
+
+	person := suite.readOutput("models/person.py")
+	suite.Contains(person, `contact_info = relationship("ContactInfo", back_populates="person", uselist=False)`)
+	suite.Contains(person, `company = relationship("Company", back_populates="person")`)
+	// The ForOne side holds the foreign key, so SQLAlchemy already maps it as a scalar
+	suite.Contains(suite.readOutput("models/contact_info.py"), `person = relationship("Person", back_populates="contact_info")`)
+
+	runPythonScript(&suite.Suite, suite.TestDirPath, `
+import sys
+import types
+from types import SimpleNamespace
+
+sqlalchemy = types.ModuleType('sqlalchemy')
+sqlalchemy.select = lambda *args: None
+sqlalchemy_orm = types.ModuleType('sqlalchemy.orm')
+sqlalchemy_orm.aliased = lambda *args: None
+sys.modules['sqlalchemy'] = sqlalchemy
+sys.modules['sqlalchemy.orm'] = sqlalchemy_orm
+
+from working.entities.person import Person
+
+# The HasOne hop reads through the related row as a scalar
+model = SimpleNamespace(id_=1, last_name='Lovelace', nationality=None, company_id='2', contact_info=SimpleNamespace(email='ada@example.com'))
+person = Person.from_model(model)
+assert person.email == 'ada@example.com' and person.id_ == 1
+
+model.contact_info = None
+person = Person.from_model(model)
+assert person.email is None and person.last_name == 'Lovelace'
+`)
+}
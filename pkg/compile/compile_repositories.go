@@ -0,0 +1,222 @@
+package compile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	"github.com/kalo-build/morphe-go/pkg/yaml"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+// CompileAllRepositories compiles a repository for every entity and writes them using the writer
+func CompileAllRepositories(config MorpheCompileConfig, r *registry.Registry, writer *MorpheWriter) error {
+	repositoryContents := make(map[string][]byte)
+
+	for entityName, entity := range r.GetAllEntities() {
+		compiledEntity, err := CompileEntity(entity, config.FormatConfig, r)
+		if err != nil {
+			return fmt.Errorf("failed to compile entity %s: %w", entityName, err)
+		}
+
+		// Repositories query through the entity's select(), which needs fields
+		mapping, err := compileEntityMapping(entity, config.FormatConfig, r)
+		if err != nil {
+			return fmt.Errorf("failed to compile model mapping for entity %s: %w", entityName, err)
+		}
+		if mapping == nil {
+			continue
+		}
+
+		repositoryContents[entityName] = generateRepositoryContent(compiledEntity, entity, config, r)
+	}
+
+	return writer.WriteAllRepositories(repositoryContents)
+}
+
+// identifierLookup is an identifier looked up by a get_by_ method
+type identifierLookup struct {
+	MethodSuffix string
+	IsPrimary    bool
+	Description  string
+	Attributes   []string
+	Params       []string
+}
+
+// getEntityIdentifierFields returns the fields of each entity identifier
+func getEntityIdentifierFields(entity yaml.Entity) map[string][]string {
+	identifierFields := make(map[string][]string)
+	for name, identifier := range entity.Identifiers {
+		identifierFields[name] = identifier.Fields
+	}
+	return identifierFields
+}
+
+// getModelIdentifierFields returns the fields of each model identifier
+func getModelIdentifierFields(model yaml.Model) map[string][]string {
+	identifierFields := make(map[string][]string)
+	for name, identifier := range model.Identifiers {
+		identifierFields[name] = identifier.Fields
+	}
+	return identifierFields
+}
+
+// getIdentifierLookups returns the identifiers in method order, primary first
+func getIdentifierLookups(fields []formatdef.Field, identifierFields map[string][]string, config SQLAlchemyConfig) []identifierLookup {
+	fieldTypes := make(map[string]string)
+	for _, field := range fields {
+		fieldTypes[field.Name] = field.Type.GetName()
+	}
+
+	var identifierNames []string
+	for name := range identifierFields {
+		if name != "primary" {
+			identifierNames = append(identifierNames, name)
+		}
+	}
+	sort.Strings(identifierNames)
+	if _, hasPrimary := identifierFields["primary"]; hasPrimary {
+		identifierNames = append([]string{"primary"}, identifierNames...)
+	}
+
+	var identifiers []identifierLookup
+	for _, identifierName := range identifierNames {
+		identifier := identifierLookup{
+			MethodSuffix: formatdef.ToSnakeCase(identifierName),
+			Description:  fmt.Sprintf("%s identifier", identifierName),
+		}
+		var snakeFields []string
+		for _, fieldName := range identifierFields[identifierName] {
+			attribute := SanitizePythonIdentifier(formatdef.ToSnakeCase(fieldName))
+			identifier.Attributes = append(identifier.Attributes, attribute)
+			snakeFields = append(snakeFields, formatdef.ToSnakeCase(fieldName))
+			if config.AddTypeHints {
+				identifier.Params = append(identifier.Params, fmt.Sprintf("%s: %s", attribute, fieldTypes[fieldName]))
+			} else {
+				identifier.Params = append(identifier.Params, attribute)
+			}
+		}
+		// The primary identifier is named after its fields, e.g. get_by_id
+		if identifierName == "primary" {
+			identifier.IsPrimary = true
+			identifier.MethodSuffix = strings.Join(snakeFields, "_and_")
+		}
+		identifiers = append(identifiers, identifier)
+	}
+	return identifiers
+}
+
+// generateRepositoryContent generates the repository of an entity
+func generateRepositoryContent(entity *formatdef.Struct, morpheEntity yaml.Entity, config MorpheCompileConfig, r *registry.Registry) []byte {
+	formatConfig := config.FormatConfig
+	isAsync := getLazyLoadingStyle(config.MorpheConfig.Entities) == "async"
+	identifiers := getIdentifierLookups(entity.Fields, getEntityIdentifierFields(morpheEntity), formatConfig)
+
+	cb := formatdef.NewContentBuilder("    ")
+	cb.Line("# Repository for %s entities", entity.Name)
+	cb.Line("")
+
+	imports := NewImportTracker(r)
+	if isAsync {
+		imports.AddFrom("sqlalchemy.ext.asyncio", "AsyncSession")
+	} else {
+		imports.AddFrom("sqlalchemy.orm", "Session")
+	}
+	imports.AddFrom("..entities."+toFileName(entity.Name), entity.Name)
+	imports.AddTyping("Any", "Dict", "List", "Optional")
+	for _, identifier := range identifiers {
+		for _, param := range identifier.Params {
+			if _, typeName, hasType := strings.Cut(param, ": "); hasType {
+				imports.TrackFieldType(typeName)
+			}
+		}
+	}
+	imports.Generate(cb)
+	cb.Line("")
+
+	sessionType := "Session"
+	def := "def"
+	execute := "self.session.execute(query)"
+	if isAsync {
+		sessionType = "AsyncSession"
+		def = "async def"
+		execute = "await self.session.execute(query)"
+	}
+	executeFirst := execute + ".first()"
+	if isAsync {
+		executeFirst = "(" + execute + ").first()"
+	}
+
+	cb.Line("class %sRepository:", entity.Name)
+	cb.Indent()
+	cb.Line(`"""Reads %s entities through %s.select()."""`, entity.Name, entity.Name)
+	cb.Line("")
+	cb.Line("def __init__(self, session: %s):", sessionType)
+	cb.Indent()
+	cb.Line("self.session = session")
+	cb.Dedent()
+
+	for _, identifier := range identifiers {
+		cb.Line("")
+		cb.Line("%s get_by_%s(self, %s) -> Optional[%s]:", def, identifier.MethodSuffix, strings.Join(identifier.Params, ", "), entity.Name)
+		cb.Indent()
+		cb.Line(`"""Get a %s by its %s."""`, entity.Name, identifier.Description)
+		cb.Line("query = %s.select()", entity.Name)
+		cb.Line("query = query.where(%s)", renderIdentifierCondition(identifier))
+		cb.Line("row = %s", executeFirst)
+		cb.Line("return %s.from_row(row) if row is not None else None", entity.Name)
+		cb.Dedent()
+	}
+
+	cb.Line("")
+	cb.Line("%s list_all(self, filters: Optional[Dict[str, Any]] = None, limit: Optional[int] = None, offset: Optional[int] = None) -> List[%s]:", def, entity.Name)
+	cb.Indent()
+	cb.Line(`"""List %s entities whose attributes equal the filter values."""`, entity.Name)
+	cb.Line("query = %s.select()", entity.Name)
+	cb.Line("for attribute, value in (filters or {}).items():")
+	cb.Indent()
+	cb.Line("if attribute not in query.selected_columns:")
+	cb.Indent()
+	cb.Line(`raise ValueError(f"unknown %s attribute: {attribute}")`, entity.Name)
+	cb.Dedent()
+	cb.Line("query = query.where(query.selected_columns[attribute] == value)")
+	cb.Dedent()
+	cb.Line("if limit is not None:")
+	cb.Indent()
+	cb.Line("query = query.limit(limit)")
+	cb.Dedent()
+	cb.Line("if offset is not None:")
+	cb.Indent()
+	cb.Line("query = query.offset(offset)")
+	cb.Dedent()
+	cb.Line("result = %s", execute)
+	cb.Line("return [%s.from_row(row) for row in result]", entity.Name)
+	cb.Dedent()
+
+	if len(identifiers) > 0 && identifiers[0].IsPrimary {
+		primary := identifiers[0]
+		cb.Line("")
+		cb.Line("%s exists(self, %s) -> bool:", def, strings.Join(primary.Params, ", "))
+		cb.Indent()
+		cb.Line(`"""Check whether a %s with the primary identifier exists."""`, entity.Name)
+		cb.Line("query = %s.select()", entity.Name)
+		cb.Line("query = query.where(%s).limit(1)", renderIdentifierCondition(primary))
+		cb.Line("row = %s", executeFirst)
+		cb.Line("return row is not None")
+		cb.Dedent()
+	}
+	cb.CustomRegion()
+
+	cb.Dedent()
+	return cb.Build()
+}
+
+// renderIdentifierCondition renders the WHERE condition matching an identifier's parameters
+func renderIdentifierCondition(identifier identifierLookup) string {
+	var conditions []string
+	for _, attribute := range identifier.Attributes {
+		conditions = append(conditions, fmt.Sprintf("query.selected_columns.%s == %s", attribute, attribute))
+	}
+	return strings.Join(conditions, ", ")
+}
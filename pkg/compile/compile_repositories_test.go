@@ -0,0 +1,228 @@
+package compile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+)
+
+// pythonRepositoryPrelude stubs the sessions imported by the repositories and replaces the entities'
+// select() with queries recording their conditions, so the repositories run against fake sessions
+const pythonRepositoryPrelude = `
+import asyncio
+
+sqlalchemy_orm.Session = object
+sqlalchemy_asyncio = types.ModuleType('sqlalchemy.ext.asyncio')
+sqlalchemy_asyncio.AsyncSession = object
+sys.modules['sqlalchemy.ext'] = types.ModuleType('sqlalchemy.ext')
+sys.modules['sqlalchemy.ext.asyncio'] = sqlalchemy_asyncio
+
+from working.repositories.comment import CommentRepository
+from working.repositories.person import PersonRepository
+
+PersonRow = namedtuple('PersonRow', 'first_name id_ last_name')
+
+
+class FakeColumn:
+    def __init__(self, name):
+        self.name = name
+
+    def __eq__(self, value):
+        return (self.name, value)
+
+    __hash__ = object.__hash__
+
+
+class FakeColumns(dict):
+    def __getattr__(self, name):
+        return self[name]
+
+
+class FakeQuery:
+    def __init__(self, names):
+        self.selected_columns = FakeColumns({name: FakeColumn(name) for name in names})
+        self.conditions = []
+        self.limit_value = None
+        self.offset_value = None
+
+    def where(self, *conditions):
+        self.conditions.extend(conditions)
+        return self
+
+    def limit(self, limit):
+        self.limit_value = limit
+        return self
+
+    def offset(self, offset):
+        self.offset_value = offset
+        return self
+
+
+class FakeResult(list):
+    def first(self):
+        return self[0] if self else None
+
+
+class FakeSession:
+    def __init__(self, rows):
+        self.rows = rows
+        self.queries = []
+
+    def execute(self, query):
+        self.queries.append(query)
+        return FakeResult(self.rows)
+
+
+class FakeAsyncSession(FakeSession):
+    async def execute(self, query):
+        return FakeSession.execute(self, query)
+
+
+def run(value):
+    return asyncio.run(value) if asyncio.iscoroutine(value) else value
+
+
+Comment.select = classmethod(lambda cls: FakeQuery(CommentRow._fields))
+Person.select = classmethod(lambda cls: FakeQuery(PersonRow._fields))
+`
+
+type CompileRepositoriesTestSuite struct {
+	generatedCodeFixture
+}
+
+func TestCompileRepositoriesTestSuite(t *testing.T) {
+	suite.Run(t, new(CompileRepositoriesTestSuite))
+}
+
+func (suite *CompileRepositoriesTestSuite) SetupTest() {
+	suite.setupFixture("entities", pythonEntityPrelude+pythonRepositoryPrelude)
+}
+
+func (suite *CompileRepositoriesTestSuite) TearDownTest() {
+	suite.tearDownFixture()
+}
+
+func (suite *CompileRepositoriesTestSuite) compileRepositories(lazyLoadingStyle string) {
+	suite.compile(compile.SQLAlchemyConfig{}, cfg.MorpheConfig{
+		Entities: cfg.EntityConfig{
+			GenerateRepository: true,
+			LazyLoadingStyle:   lazyLoadingStyle,
+		},
+	})
+}
+
+// runWithSession runs a script against the generated repositories with the given fake session class
+func (suite *CompileRepositoriesTestSuite) runWithSession(sessionClass string, script string) {
+	suite.runPython("\nSession = " + sessionClass + "\n" + script)
+}
+
+func (suite *CompileRepositoriesTestSuite) TestRepository_AsyncSession() {
+	suite.compileRepositories("")
+
+	comment := suite.readOutput("repositories/comment.py")
+	suite.Contains(comment, "from sqlalchemy.ext.asyncio import AsyncSession")
+	suite.Contains(comment, "def __init__(self, session: AsyncSession):")
+	suite.Contains(comment, "async def get_by_id(self, id_: int) -> Optional[Comment]:")
+	suite.Contains(comment, "row = (await self.session.execute(query)).first()")
+	suite.Contains(comment, "result = await self.session.execute(query)")
+
+	suite.runWithSession("FakeAsyncSession", repositoryChecks)
+}
+
+func (suite *CompileRepositoriesTestSuite) TestRepository_Session() {
+	for _, lazyLoadingStyle := range []string{"sync", "property"} {
+		suite.compileRepositories(lazyLoadingStyle)
+
+		comment := suite.readOutput("repositories/comment.py")
+		suite.Contains(comment, "from sqlalchemy.orm import Session")
+		suite.Contains(comment, "def __init__(self, session: Session):")
+		suite.Contains(comment, "    def get_by_id(self, id_: int) -> Optional[Comment]:")
+		suite.Contains(comment, "row = self.session.execute(query).first()")
+		suite.NotContains(comment, "async def")
+		suite.NotContains(comment, "await")
+	}
+
+	suite.runWithSession("FakeSession", repositoryChecks)
+}
+
+func (suite *CompileRepositoriesTestSuite) TestRepository_GetByIdentifiers() {
+	suite.compileRepositories("")
+
+	comment := suite.readOutput("repositories/comment.py")
+	suite.Contains(comment, "async def get_by_slug(self, slug: str) -> Optional[Comment]:")
+	suite.Contains(comment, "query = query.where(query.selected_columns.slug == slug)")
+	person := suite.readOutput("repositories/person.py")
+	suite.Contains(person, "async def get_by_first_name_and_last_name(self, first_name: str, last_name: str) -> Optional[Person]:")
+	suite.Contains(person, "query = query.where(query.selected_columns.first_name == first_name, query.selected_columns.last_name == last_name)")
+	// Only the primary identifier gets exists()
+	suite.Contains(person, "async def exists(self, first_name: str, last_name: str) -> bool:")
+	suite.NotContains(person, "get_by_name")
+}
+
+func (suite *CompileRepositoriesTestSuite) TestRepository_ListAll() {
+	suite.compileRepositories("")
+
+	comment := suite.readOutput("repositories/comment.py")
+	suite.Contains(comment, "async def list_all(self, filters: Optional[Dict[str, Any]] = None, limit: Optional[int] = None, offset: Optional[int] = None) -> List[Comment]:")
+
+	suite.runWithSession("FakeAsyncSession", `
+rows = [
+    CommentRow('Hello', 1, 'hello', Status.DRAFT, 'Person', '1'),
+    CommentRow('World', 2, 'world', Status.DRAFT, 'Company', '2'),
+]
+repository = CommentRepository(Session(rows))
+
+comments = run(repository.list_all())
+assert [comment.id_ for comment in comments] == [1, 2]
+query = repository.session.queries[-1]
+assert query.conditions == [] and query.limit_value is None and query.offset_value is None
+
+run(repository.list_all({'status': Status.DRAFT, 'commentable_type': 'Person'}, limit=10, offset=20))
+query = repository.session.queries[-1]
+assert query.conditions == [('status', Status.DRAFT), ('commentable_type', 'Person')], query.conditions
+assert query.limit_value == 10
+assert query.offset_value == 20
+
+run(repository.list_all(filters={'slug': 'hello'}, offset=0))
+query = repository.session.queries[-1]
+assert query.conditions == [('slug', 'hello')]
+assert query.limit_value is None and query.offset_value == 0
+
+try:
+    run(repository.list_all({'title': 'Hello'}))
+except ValueError as e:
+    assert str(e) == 'unknown Comment attribute: title', str(e)
+else:
+    raise AssertionError('expected ValueError')
+`)
+}
+
+// repositoryChecks looks entities up by their identifiers through either session
+const repositoryChecks = `
+row = CommentRow('Hello', 1, 'hello', None, 'Person', '1')
+comments = CommentRepository(Session([row]))
+
+comment = run(comments.get_by_id(1))
+assert comment.id_ == 1 and comment.body == 'Hello'
+assert comments.session.queries[-1].conditions == [('id_', 1)]
+
+comment = run(comments.get_by_slug('hello'))
+assert comment.slug == 'hello'
+assert comments.session.queries[-1].conditions == [('slug', 'hello')]
+
+assert run(CommentRepository(Session([])).get_by_id(2)) is None
+
+people = PersonRepository(Session([PersonRow('Ada', 1, 'Lovelace')]))
+person = run(people.get_by_first_name_and_last_name('Ada', 'Lovelace'))
+assert person.get_id() == ('Ada', 'Lovelace')
+assert people.session.queries[-1].conditions == [('first_name', 'Ada'), ('last_name', 'Lovelace')]
+
+assert run(comments.exists(1)) is True
+query = comments.session.queries[-1]
+assert query.conditions == [('id_', 1)] and query.limit_value == 1
+assert run(CommentRepository(Session([])).exists(1)) is False
+assert run(people.exists('Ada', 'Lovelace')) is True
+`
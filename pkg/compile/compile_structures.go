@@ -3,9 +3,11 @@ package compile
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/kalo-build/morphe-go/pkg/registry"
 	"github.com/kalo-build/morphe-go/pkg/yaml"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
 	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
 	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/typemap"
 )
@@ -31,7 +33,7 @@ func CompileStructure(structure yaml.Structure, r *registry.Registry) (*formatde
 		// Map field type to format type
 		fieldType, err := typemap.MorpheStructureFieldToFormatType(field.Type, fieldName, r)
 		if err != nil {
-			return nil, fmt.Errorf("failed to map field type for %s: %w", fieldName, err)
+			return nil, fmt.Errorf("failed to map field type for %s: %w: %w", fieldName, ErrInvalidFieldType(string(field.Type)), err)
 		}
 
 		formatField := formatdef.Field{
@@ -48,6 +50,13 @@ func CompileStructure(structure yaml.Structure, r *registry.Registry) (*formatde
 func CompileAllStructures(config MorpheCompileConfig, r *registry.Registry, writer *MorpheWriter) error {
 	structureContents := make(map[string][]byte)
 
+	// Structures nest by value, so a cycle can never be constructed
+	if cycles := DetectStructureCycles(r.GetAllStructures()); len(cycles) > 0 {
+		return fmt.Errorf("structures can't contain themselves: %s", cycles[0].String())
+	}
+
+	usages := getStructureUsages(config.FormatConfig, r)
+
 	// Process each structure in the registry
 	for structureName, structure := range r.GetAllStructures() {
 		// Compile the structure
@@ -56,8 +65,13 @@ func CompileAllStructures(config MorpheCompileConfig, r *registry.Registry, writ
 			return fmt.Errorf("failed to compile structure %s: %w", structureName, err)
 		}
 
+		usage := usages[structureName]
+		if config.FormatConfig.MutableStructures && usage.Composite && usage.JSON {
+			return fmt.Errorf("structure %s is stored both as composite and as JSON, mutation tracking supports only one storage per structure", structureName)
+		}
+
 		// Generate the content for this structure
-		content := generateStructureContent(compiledStructure, config.FormatConfig)
+		content := generateStructureContent(compiledStructure, config.FormatConfig, config.MorpheConfig.Structures, usage, r)
 		structureContents[structureName] = content
 	}
 
@@ -65,77 +79,303 @@ func CompileAllStructures(config MorpheCompileConfig, r *registry.Registry, writ
 	return writer.WriteAllStructures(structureContents)
 }
 
-// generateStructureContent generates Python structure as a DTO with concrete fields
-func generateStructureContent(structure *formatdef.Struct, config SQLAlchemyConfig) []byte {
-	cb := formatdef.NewContentBuilder("    ")
-
-	// Add header comment
-	cb.Line("# Code generated by Morphe")
-	cb.Line("# Structure DTO (Data Transfer Object)")
-	cb.Line("")
+// structureUsage records how model fields of a structure's type are stored
+type structureUsage struct {
+	Composite bool
+	JSON      bool
+}
 
-	// Add imports
-	if config.UseDataclass {
-		cb.Line("from dataclasses import dataclass")
+// getStructureUsages returns the storages used for each structure by the generated models
+func getStructureUsages(config SQLAlchemyConfig, r *registry.Registry) map[string]structureUsage {
+	usages := make(map[string]structureUsage)
+	for modelName, model := range r.GetAllModels() {
+		if getTableConfig(modelName, config).Skip {
+			continue
+		}
+		for fieldName, field := range model.Fields {
+			structureName := string(field.Type)
+			if resolveFieldType(structureName, r) != "structure" {
+				continue
+			}
+			usage := usages[structureName]
+			if getStructureStorage(modelName, fieldName, config) == StructureStorageComposite {
+				usage.Composite = true
+			} else {
+				usage.JSON = true
+			}
+			usages[structureName] = usage
+		}
 	}
 
-	if config.AddTypeHints {
-		imports := []string{"Optional"}
-		hasDate := false
-		hasDict := false
-		hasList := false
-
-		// Check if we need additional imports
+	// Nested structures are serialized as part of their parent's JSON document
+	var pending []string
+	for structureName, usage := range usages {
+		if usage.JSON {
+			pending = append(pending, structureName)
+		}
+	}
+	for len(pending) > 0 {
+		structure, err := r.GetStructure(pending[0])
+		pending = pending[1:]
+		if err != nil {
+			continue
+		}
 		for _, field := range structure.Fields {
-			typeName := field.Type.GetName()
-			if typeName == "datetime" {
-				hasDate = true
-			} else if typeName == "Dict[str, Any]" {
-				hasDict = true
-			} else if len(typeName) > 5 && typeName[:5] == "List[" {
-				hasList = true
+			nestedName := string(field.Type)
+			if resolveFieldType(nestedName, r) != "structure" || usages[nestedName].JSON {
+				continue
 			}
+			usage := usages[nestedName]
+			usage.JSON = true
+			usages[nestedName] = usage
+			pending = append(pending, nestedName)
 		}
+	}
+	return usages
+}
 
-		if hasDict {
-			imports = append(imports, "Dict", "Any")
-		}
-		if hasList {
-			imports = append(imports, "List")
-		}
+// generateStructureContent generates Python structure as a DTO with concrete fields
+func generateStructureContent(structure *formatdef.Struct, config SQLAlchemyConfig, structureConfig cfg.StructureConfig, usage structureUsage, r *registry.Registry) []byte {
+	useDataclass := structureConfig.UseDataclass || config.UseDataclass
+	// dataclass(slots=True) needs Python 3.10, older targets declare __slots__ explicitly
+	dataclassSlots := useDataclass && structureConfig.GenerateSlots && pythonVersionAtLeast(config.PythonVersion, 3, 10)
+	explicitSlots := structureConfig.GenerateSlots && !dataclassSlots
 
-		if len(imports) > 0 {
-			cb.Line("from typing import %s", formatdef.FormatList(imports, ", "))
-		}
+	cb := formatdef.NewContentBuilder("    ")
 
-		if hasDate {
-			cb.Line("from datetime import datetime")
-		}
+	// Add header comment
+	cb.Line("# Structure DTO (Data Transfer Object)")
+	cb.Line("")
+
+	// Only structures tracked in models of a single storage kind get a mutable base class
+	baseClass := ""
+	if config.MutableStructures && usage.Composite {
+		baseClass = "MutableComposite"
+	} else if config.MutableStructures && usage.JSON {
+		baseClass = "Mutable"
 	}
 
-	cb.Line("")
+	// Add imports
+	imports := NewImportTracker(r)
+	imports.SetStructuresPackage(".")
+	if useDataclass {
+		imports.AddFrom("dataclasses", "dataclass")
+	}
+	if baseClass != "" {
+		imports.AddFrom("sqlalchemy.ext.mutable", baseClass)
+	}
+	if config.AddTypeHints {
+		imports.AddTyping("Optional")
+	}
+	// Annotations are evaluated at class creation, so every referenced type is imported
+	for _, field := range structure.Fields {
+		imports.TrackFieldType(field.Type.GetName())
+	}
+	imports.Generate(cb)
 	cb.Line("")
 
 	// Generate class
-	if config.UseDataclass {
-		cb.Line("@dataclass")
-		cb.Line("class %s:", structure.Name)
-	} else {
-		cb.Line("class %s:", structure.Name)
+	classDecl := structure.Name
+	if baseClass != "" {
+		classDecl = fmt.Sprintf("%s(%s)", structure.Name, baseClass)
+	}
+	if useDataclass {
+		var options []string
+		if dataclassSlots {
+			options = append(options, "slots=True")
+		}
+		if structureConfig.Frozen {
+			options = append(options, "frozen=True")
+		}
+		if len(options) > 0 {
+			cb.Line("@dataclass(%s)", strings.Join(options, ", "))
+		} else {
+			cb.Line("@dataclass")
+		}
 	}
+	cb.Line("class %s:", classDecl)
 	cb.Indent()
 
 	// Add docstring
 	cb.Line(`"""%s data transfer object."""`, structure.Name)
 
+	var attributeNames []string
+	for _, field := range structure.Fields {
+		attributeNames = append(attributeNames, SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name)))
+	}
+	if explicitSlots {
+		var slots []string
+		for _, attributeName := range attributeNames {
+			slots = append(slots, quotePythonString(attributeName))
+		}
+		if len(slots) == 1 {
+			cb.Line("__slots__ = (%s,)", slots[0])
+		} else {
+			cb.Line("__slots__ = (%s)", strings.Join(slots, ", "))
+		}
+	}
+
 	// Add fields
+	var params []string
+	attributeTypes := make(map[string]string)
 	for _, field := range structure.Fields {
 		fieldName := SanitizePythonIdentifier(formatdef.ToSnakeCase(field.Name))
 		fieldType := field.Type.GetName()
 		cb.Line("%s: %s", fieldName, fieldType)
+
+		attributeTypes[fieldName] = fieldType
+		if config.AddTypeHints {
+			params = append(params, fmt.Sprintf("%s: %s", fieldName, fieldType))
+		} else {
+			params = append(params, fieldName)
+		}
+	}
+
+	// Positional constructor in field order, as used by composite() when loading rows
+	if !useDataclass && len(attributeNames) > 0 {
+		cb.Line("")
+		cb.Line("def __init__(self, %s):", strings.Join(params, ", "))
+		cb.Indent()
+		for _, attributeName := range attributeNames {
+			cb.Line("self.%s = %s", attributeName, attributeName)
+		}
+		cb.Dedent()
+	}
+
+	// Column values of the structure, only needed where a model maps it with composite()
+	if usage.Composite {
+		cb.Line("")
+		cb.Line("def __composite_values__(self):")
+		cb.Indent()
+		cb.Line("return %s", renderAttributeTuple("self", attributeNames))
+		cb.Dedent()
 	}
 
+	// Plain classes compare and print by value like dataclasses
+	if !useDataclass {
+		cb.Line("")
+		cb.Line("def __eq__(self, other):")
+		cb.Indent()
+		cb.Line("if not isinstance(other, %s):", structure.Name)
+		cb.Indent()
+		cb.Line("return NotImplemented")
+		cb.Dedent()
+		cb.Line("return %s == %s", renderAttributeTuple("self", attributeNames), renderAttributeTuple("other", attributeNames))
+		cb.Dedent()
+
+		cb.Line("")
+		cb.Line("def __repr__(self):")
+		cb.Indent()
+		var reprFields []string
+		for _, attributeName := range attributeNames {
+			reprFields = append(reprFields, fmt.Sprintf("%s={self.%s!r}", attributeName, attributeName))
+		}
+		cb.Line(`return f"%s(%s)"`, structure.Name, strings.Join(reprFields, ", "))
+		cb.Dedent()
+	}
+
+	// Dict form of the structure as stored in JSON columns
+	if usage.JSON {
+		cb.Line("")
+		cb.Line("def to_dict(self):")
+		cb.Indent()
+		cb.Line("return {")
+		cb.Indent()
+		for _, attributeName := range attributeNames {
+			cb.Line("'%s': %s,", attributeName, renderJSONDump(attributeName, attributeTypes[attributeName], r))
+		}
+		cb.Dedent()
+		cb.Line("}")
+		cb.Dedent()
+
+		cb.Line("")
+		cb.Line("@classmethod")
+		cb.Line("def from_dict(cls, data):")
+		cb.Indent()
+		cb.Line("return cls(")
+		cb.Indent()
+		for _, attributeName := range attributeNames {
+			cb.Line("%s=%s,", attributeName, renderJSONLoad(attributeName, attributeTypes[attributeName], r))
+		}
+		cb.Dedent()
+		cb.Line(")")
+		cb.Dedent()
+	}
+
+	// In-place attribute changes mark the owning model as modified
+	if baseClass != "" {
+		cb.Line("")
+		cb.Line("def __setattr__(self, key, value):")
+		cb.Indent()
+		cb.Line("object.__setattr__(self, key, value)")
+		cb.Line("self.changed()")
+		cb.Dedent()
+	}
+	if baseClass == "Mutable" {
+		cb.Line("")
+		cb.Line("@classmethod")
+		cb.Line("def coerce(cls, key, value):")
+		cb.Indent()
+		cb.Line("if isinstance(value, dict):")
+		cb.Indent()
+		cb.Line("return cls.from_dict(value)")
+		cb.Dedent()
+		cb.Line("if isinstance(value, cls):")
+		cb.Indent()
+		cb.Line("return value")
+		cb.Dedent()
+		cb.Line("return Mutable.coerce(key, value)")
+		cb.Dedent()
+	}
+	cb.CustomRegion()
+
 	cb.Dedent()
 
 	return cb.Build()
 }
+
+// renderJSONDump renders the JSON-compatible value of a structure attribute for to_dict()
+func renderJSONDump(attributeName string, typeName string, r *registry.Registry) string {
+	value := "self." + attributeName
+	var converted string
+	switch {
+	case typeName == "datetime":
+		converted = value + ".isoformat()"
+	case resolveFieldType(typeName, r) == "enum":
+		converted = value + ".value"
+	case resolveFieldType(typeName, r) == "structure":
+		converted = value + ".to_dict()"
+	default:
+		return value
+	}
+	return fmt.Sprintf("%s if %s is not None else None", converted, value)
+}
+
+// renderJSONLoad renders the conversion of a JSON value back to a structure attribute for from_dict()
+func renderJSONLoad(attributeName string, typeName string, r *registry.Registry) string {
+	value := fmt.Sprintf("data.get('%s')", attributeName)
+	var converted string
+	switch {
+	case typeName == "datetime":
+		converted = fmt.Sprintf("datetime.fromisoformat(%s)", value)
+	case resolveFieldType(typeName, r) == "enum":
+		converted = fmt.Sprintf("%s(%s)", typeName, value)
+	case resolveFieldType(typeName, r) == "structure":
+		converted = fmt.Sprintf("%s.from_dict(%s)", typeName, value)
+	default:
+		return value
+	}
+	return fmt.Sprintf("%s if %s is not None else None", converted, value)
+}
+
+// renderAttributeTuple renders a Python tuple of the named attributes of an object
+func renderAttributeTuple(object string, attributeNames []string) string {
+	values := make([]string, 0, len(attributeNames))
+	for _, attributeName := range attributeNames {
+		values = append(values, object+"."+attributeName)
+	}
+	if len(values) == 1 {
+		return fmt.Sprintf("(%s,)", values[0])
+	}
+	return fmt.Sprintf("(%s)", strings.Join(values, ", "))
+}
@@ -0,0 +1,177 @@
+package compile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
+	"github.com/kalo-build/morphe-go/pkg/yaml"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/internal/testutils"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+)
+
+type CompileStructuresTestSuite struct {
+	suite.Suite
+
+	Registry       *registry.Registry
+	RegistryConfig rcfg.MorpheLoadRegistryConfig
+}
+
+func TestCompileStructuresTestSuite(t *testing.T) {
+	suite.Run(t, new(CompileStructuresTestSuite))
+}
+
+func (suite *CompileStructuresTestSuite) SetupTest() {
+	registryDirPath := filepath.Join(testutils.GetTestDirPath(), "registry", "minimal")
+	suite.RegistryConfig = rcfg.MorpheLoadRegistryConfig{
+		RegistryEnumsDirPath:      filepath.Join(registryDirPath, "enums"),
+		RegistryStructuresDirPath: filepath.Join(registryDirPath, "structures"),
+		RegistryModelsDirPath:     filepath.Join(registryDirPath, "models"),
+		RegistryEntitiesDirPath:   filepath.Join(registryDirPath, "entities"),
+	}
+	r, err := registry.LoadMorpheRegistry(registry.LoadMorpheRegistryHooks{}, suite.RegistryConfig)
+	suite.Require().NoError(err)
+	suite.Registry = r
+}
+
+func (suite *CompileStructuresTestSuite) TestCompileStructure_UnknownFieldType() {
+	structure := yaml.Structure{
+		Name: "Parcel",
+		Fields: map[string]yaml.StructureField{
+			"Weight": {Type: "Kilograms"},
+		},
+	}
+
+	_, err := compile.CompileStructure(structure, suite.Registry)
+
+	// Both the field type and the reason it couldn't be mapped are reported
+	suite.ErrorContains(err, "failed to map field type for Weight: invalid or unsupported field type: Kilograms: unknown type Kilograms of structure field Weight")
+}
+
+// compileStructures compiles the registry with the structure options and returns the output directory
+func (suite *CompileStructuresTestSuite) compileStructures(pythonVersion string, structureConfig cfg.StructureConfig) string {
+	testDirPath := suite.T().TempDir()
+	config := compile.MorpheCompileConfig{
+		MorpheLoadRegistryConfig: suite.RegistryConfig,
+		OutputPath:               filepath.Join(testDirPath, "working"),
+		FormatConfig: compile.SQLAlchemyConfig{
+			UseDeclarative: true,
+			AddTypeHints:   true,
+			IndentSize:     4,
+			PythonVersion:  pythonVersion,
+		},
+	}
+	config.MorpheConfig.Structures = structureConfig
+	suite.Require().NoError(compile.MorpheToSQLAlchemy(config))
+	return testDirPath
+}
+
+func (suite *CompileStructuresTestSuite) readStructure(testDirPath string, fileName string) string {
+	content, err := os.ReadFile(filepath.Join(testDirPath, "working", "structures", fileName))
+	suite.Require().NoError(err)
+	return string(content)
+}
+
+func (suite *CompileStructuresTestSuite) TestCompileStructure_Dataclass() {
+	testDirPath := suite.compileStructures("3.8", cfg.StructureConfig{UseDataclass: true})
+
+	address := suite.readStructure(testDirPath, "address.py")
+	suite.Contains(address, "from dataclasses import dataclass")
+	suite.Contains(address, "@dataclass\nclass Address:")
+	// Dataclasses generate their own __init__, __eq__ and __repr__
+	suite.NotContains(address, "def __init__")
+	suite.NotContains(address, "def __eq__")
+	suite.Contains(address, "def __composite_values__(self):")
+
+	runPythonScript(&suite.Suite, testDirPath, `
+from working.structures.address import Address
+
+address = Address('Berlin', '1', 'Main', '10115')
+assert address == Address(city='Berlin', house_nr='1', street='Main', zip_code='10115')
+assert address.__composite_values__() == ('Berlin', '1', 'Main', '10115')
+address.city = 'Hamburg'
+assert address.city == 'Hamburg'
+`)
+}
+
+func (suite *CompileStructuresTestSuite) TestCompileStructure_DataclassSlots() {
+	testDirPath := suite.compileStructures("3.10", cfg.StructureConfig{UseDataclass: true, GenerateSlots: true})
+
+	address := suite.readStructure(testDirPath, "address.py")
+	suite.Contains(address, "@dataclass(slots=True)\nclass Address:")
+	suite.NotContains(address, "__slots__")
+
+	// dataclass(slots=True) needs Python 3.10, older targets declare __slots__ explicitly
+	testDirPath = suite.compileStructures("3.9", cfg.StructureConfig{UseDataclass: true, GenerateSlots: true})
+
+	address = suite.readStructure(testDirPath, "address.py")
+	suite.Contains(address, "@dataclass\nclass Address:")
+	suite.Contains(address, "__slots__ = ('city', 'house_nr', 'street', 'zip_code')")
+
+	runPythonScript(&suite.Suite, testDirPath, `
+from working.structures.address import Address
+
+address = Address('Berlin', '1', 'Main', '10115')
+assert not hasattr(address, '__dict__')
+try:
+    address.country = 'Germany'
+except AttributeError:
+    pass
+else:
+    raise AssertionError('expected AttributeError')
+`)
+}
+
+func (suite *CompileStructuresTestSuite) TestCompileStructure_PlainSlots() {
+	testDirPath := suite.compileStructures("3.10", cfg.StructureConfig{GenerateSlots: true})
+
+	address := suite.readStructure(testDirPath, "address.py")
+	suite.Contains(address, "class Address:\n    \"\"\"Address data transfer object.\"\"\"\n    __slots__ = ('city', 'house_nr', 'street', 'zip_code')")
+	suite.Contains(address, "def __init__(self, city: str, house_nr: str, street: str, zip_code: str):")
+}
+
+func (suite *CompileStructuresTestSuite) TestCompileStructure_Frozen() {
+	testDirPath := suite.compileStructures("3.10", cfg.StructureConfig{UseDataclass: true, GenerateSlots: true, Frozen: true})
+
+	address := suite.readStructure(testDirPath, "address.py")
+	suite.Contains(address, "@dataclass(slots=True, frozen=True)\nclass Address:")
+
+	runPythonScript(&suite.Suite, testDirPath, `
+from dataclasses import FrozenInstanceError
+
+from working.structures.address import Address
+
+address = Address('Berlin', '1', 'Main', '10115')
+try:
+    address.city = 'Hamburg'
+except FrozenInstanceError:
+    pass
+else:
+    raise AssertionError('expected FrozenInstanceError')
+assert hash(address) == hash(Address('Berlin', '1', 'Main', '10115'))
+`)
+}
+
+func (suite *CompileStructuresTestSuite) TestCompileStructure_FrozenValidation() {
+	config := compile.MorpheCompileConfig{
+		MorpheLoadRegistryConfig: suite.RegistryConfig,
+		OutputPath:               suite.T().TempDir(),
+		FormatConfig:             compile.SQLAlchemyConfig{IndentSize: 4},
+	}
+
+	// Plain classes have no frozen form
+	config.MorpheConfig.Structures = cfg.StructureConfig{Frozen: true}
+	suite.EqualError(config.Validate(), "frozen structures require useDataclass")
+
+	config.MorpheConfig.Structures = cfg.StructureConfig{UseDataclass: true, Frozen: true}
+	suite.NoError(config.Validate())
+
+	// Mutable structures assign their fields, which frozen dataclasses reject
+	config.FormatConfig.MutableStructures = true
+	suite.EqualError(config.Validate(), "frozen structures can't be combined with mutableStructures")
+}
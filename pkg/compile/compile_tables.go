@@ -0,0 +1,612 @@
+package compile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	"github.com/kalo-build/morphe-go/pkg/yaml"
+	"github.com/kalo-build/morphe-go/pkg/yamlops"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/typemap"
+)
+
+// CompileSchema converts all models in the registry to their relational table layout
+func CompileSchema(config MorpheCompileConfig, r *registry.Registry) (*formatdef.Schema, error) {
+	schema := &formatdef.Schema{
+		Tables: make([]formatdef.Table, 0),
+	}
+
+	// Sort models for consistent output
+	allModels := r.GetAllModels()
+	var modelNames []string
+	for name := range allModels {
+		modelNames = append(modelNames, name)
+	}
+	sort.Strings(modelNames)
+
+	usedEnums := make(map[string]bool)
+	for _, modelName := range modelNames {
+		// Tables of skipped models are managed elsewhere
+		if getTableConfig(modelName, config.FormatConfig).Skip {
+			continue
+		}
+		table, err := CompileTable(allModels[modelName], config.FormatConfig, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile table for model %s: %w", modelName, err)
+		}
+		for _, column := range table.Columns {
+			if column.EnumName != "" {
+				usedEnums[column.EnumName] = true
+			}
+		}
+		schema.Tables = append(schema.Tables, *table)
+	}
+
+	// Only enums referenced by a column exist as database types
+	var enumNames []string
+	for name := range usedEnums {
+		enumNames = append(enumNames, name)
+	}
+	sort.Strings(enumNames)
+
+	for _, enumName := range enumNames {
+		enum, err := r.GetEnum(enumName)
+		if err != nil {
+			return nil, ErrEnumNotFound(enumName)
+		}
+		schema.Enums = append(schema.Enums, compileEnumDef(enum))
+	}
+
+	views, err := CompileViews(config, r)
+	if err != nil {
+		return nil, err
+	}
+	schema.Views = views
+
+	return schema, nil
+}
+
+// CompileTable converts a Morphe model to its table definition
+func CompileTable(model yaml.Model, config SQLAlchemyConfig, r *registry.Registry) (*formatdef.Table, error) {
+	table := &formatdef.Table{
+		Name:      getTableName(model.Name, config),
+		Schema:    getTableSchema(model.Name, config),
+		Comment:   getTableConfig(model.Name, config).Comment,
+		ModelName: model.Name,
+		Columns:   make([]formatdef.Column, 0),
+	}
+
+	primaryFields := make(map[string]bool)
+	if primaryId, exists := model.Identifiers["primary"]; exists {
+		for _, fieldName := range primaryId.Fields {
+			primaryFields[fieldName] = true
+		}
+	}
+
+	// Sort fields for consistent output
+	var fieldNames []string
+	for name := range model.Fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+
+	// Add data columns
+	for _, fieldName := range fieldNames {
+		field := model.Fields[fieldName]
+		fieldType := typemap.GetFieldType(field.Type)
+		isPrimaryKey := primaryFields[fieldName]
+
+		// Structure fields are stored as a JSON document or expand into one prefixed column per structure field
+		if resolveFieldType(string(field.Type), r) == "structure" {
+			if storage := getStructureStorage(model.Name, fieldName, config); storage != StructureStorageComposite {
+				jsonType := "JSON"
+				if storage == StructureStorageJSONB {
+					jsonType = "JSONB"
+				}
+				table.Columns = append(table.Columns, formatdef.Column{
+					Name:      getColumnName(model.Name, fieldName, config),
+					Attribute: getAttributeName(fieldName),
+					Field:     fieldName,
+					Structure: string(field.Type),
+					Type:      jsonType,
+					Nullable:  fieldType.IsNullable(),
+				})
+				continue
+			}
+			columns, err := compileCompositeColumns(model.Name, fieldName, string(field.Type), config, r)
+			if err != nil {
+				return nil, err
+			}
+			table.Columns = append(table.Columns, columns...)
+			continue
+		}
+
+		column := formatdef.Column{
+			Name:          getColumnName(model.Name, fieldName, config),
+			Attribute:     getAttributeName(fieldName),
+			Field:         fieldName,
+			Type:          mapFieldTypeToSQLAlchemy(fieldType),
+			Nullable:      !isPrimaryKey && fieldType.IsNullable(),
+			PrimaryKey:    isPrimaryKey,
+			AutoIncrement: isPrimaryKey && field.Type == yaml.ModelFieldTypeAutoIncrement,
+		}
+		if resolveFieldType(string(field.Type), r) == "enum" {
+			column.Type = "Enum"
+			column.EnumName = string(field.Type)
+		}
+		table.Columns = append(table.Columns, column)
+	}
+
+	// Sort related for consistent output
+	var relatedNames []string
+	for name := range model.Related {
+		relatedNames = append(relatedNames, name)
+	}
+	sort.Strings(relatedNames)
+
+	// Add relation columns
+	for _, relatedName := range relatedNames {
+		relation := model.Related[relatedName]
+		relationType := string(relation.Type)
+
+		if yamlops.IsRelationPolyFor(relationType) && yamlops.IsRelationOne(relationType) {
+			// ForOnePoly: discriminator and id columns without a foreign key
+			idType, err := getPolymorphicIdType(relation.For, config, r)
+			if err != nil {
+				return nil, err
+			}
+			table.Columns = append(table.Columns,
+				formatdef.Column{
+					Name:      getRelationColumnName(relatedName, "type", config),
+					Attribute: getAttributeName(relatedName + "_type"),
+					Field:     relatedName,
+					Type:      "String",
+				},
+				formatdef.Column{
+					Name:      getRelationColumnName(relatedName, "id", config),
+					Attribute: getAttributeName(relatedName + "_id"),
+					Field:     relatedName,
+					Type:      idType,
+				},
+			)
+		} else if yamlops.IsRelationFor(relationType) && yamlops.IsRelationOne(relationType) && !yamlops.IsRelationPoly(relationType) {
+			// Regular ForOne: foreign key to the target's primary key
+			targetName := yamlops.GetRelationTargetName(relatedName, relation.Aliased)
+			targetModel, err := r.GetModel(targetName)
+			if err != nil {
+				return nil, ErrModelNotFound(targetName)
+			}
+			targetPk, err := getPrimaryKeyColumn(targetModel, config, r)
+			if err != nil {
+				return nil, err
+			}
+
+			column := formatdef.Column{
+				Name:      getRelationColumnName(relatedName, "id", config),
+				Attribute: getAttributeName(relatedName + "_id"),
+				Field:     relatedName,
+				Type:      targetPk.Type,
+			}
+			table.Columns = append(table.Columns, column)
+
+			refTable := getTableName(targetModel.Name, config)
+			table.ForeignKeys = append(table.ForeignKeys, formatdef.ForeignKey{
+				Name:      getForeignKeyName(table, column.Name, refTable, targetPk.Name, config),
+				Column:    column.Name,
+				RefSchema: getTableSchema(targetModel.Name, config),
+				RefTable:  refTable,
+				RefColumn: targetPk.Name,
+			})
+			// Foreign key columns are indexed for joins
+			table.Indexes = append(table.Indexes, formatdef.Index{
+				Name:    getIndexName(table, []string{column.Name}, config),
+				Columns: []string{column.Name},
+			})
+		}
+		// HasOne, HasMany, ForMany and the remaining polymorphic types don't add columns
+	}
+
+	// Column names must be unique after naming strategy and overrides
+	seenColumns := make(map[string]string)
+	for _, column := range table.Columns {
+		if field, exists := seenColumns[column.Name]; exists {
+			return nil, fmt.Errorf("fields %s and %s of model %s both map to column %s", field, column.Field, model.Name, column.Name)
+		}
+		seenColumns[column.Name] = column.Field
+	}
+
+	var primaryKeyColumns []string
+	for _, column := range table.Columns {
+		if column.PrimaryKey {
+			primaryKeyColumns = append(primaryKeyColumns, column.Name)
+		}
+	}
+	if len(primaryKeyColumns) > 0 {
+		table.PrimaryKeyName = getPrimaryKeyName(table, primaryKeyColumns, config)
+	}
+
+	// Secondary identifiers become unique constraints
+	var identifierNames []string
+	for name := range model.Identifiers {
+		if name != "primary" {
+			identifierNames = append(identifierNames, name)
+		}
+	}
+	sort.Strings(identifierNames)
+
+	for _, identifierName := range identifierNames {
+		var columns []string
+		for _, fieldName := range model.Identifiers[identifierName].Fields {
+			if _, exists := model.Fields[fieldName]; !exists {
+				return nil, fmt.Errorf("identifier %s references unknown field %s", identifierName, fieldName)
+			}
+			if resolveFieldType(string(model.Fields[fieldName].Type), r) == "structure" && getStructureStorage(model.Name, fieldName, config) == StructureStorageComposite {
+				// Composite fields are unique over all of their columns
+				for _, column := range table.Columns {
+					if column.Field == fieldName && column.Composite != "" {
+						columns = append(columns, column.Name)
+					}
+				}
+				continue
+			}
+			columns = append(columns, getColumnName(model.Name, fieldName, config))
+		}
+		table.Uniques = append(table.Uniques, formatdef.UniqueConstraint{
+			Name:    getUniqueName(table, columns, config),
+			Columns: columns,
+		})
+	}
+
+	return table, nil
+}
+
+// getTableConfig returns the table overrides for a model
+func getTableConfig(modelName string, config SQLAlchemyConfig) cfg.TableConfig {
+	return config.Tables[modelName]
+}
+
+// getTableName returns the table name for a model
+func getTableName(modelName string, config SQLAlchemyConfig) string {
+	if name := getTableConfig(modelName, config).Name; name != "" {
+		return name
+	}
+	return config.TableNamePrefix + applyTableNaming(modelName, config) + config.TableNameSuffix
+}
+
+// applyTableNaming converts a model name with the configured table naming strategy
+func applyTableNaming(modelName string, config SQLAlchemyConfig) string {
+	switch config.TableNaming {
+	case TableNamingPreserve:
+		return modelName
+	case TableNamingSnakePlural:
+		return formatdef.Pluralize(formatdef.ToSnakeCase(modelName), config.Irregulars)
+	default:
+		return formatdef.ToSnakeCase(modelName)
+	}
+}
+
+// validateTableNames checks that no two generated models map to the same table
+func validateTableNames(config SQLAlchemyConfig, r *registry.Registry) error {
+	var modelNames []string
+	for modelName := range r.GetAllModels() {
+		// Skipped tables are managed elsewhere, so they can share a name with a generated one
+		if getTableConfig(modelName, config).Skip {
+			continue
+		}
+		modelNames = append(modelNames, modelName)
+	}
+	sort.Strings(modelNames)
+
+	owners := make(map[string]string)
+	for _, modelName := range modelNames {
+		tableName := getTableName(modelName, config)
+		if schema := getTableSchema(modelName, config); schema != "" {
+			tableName = schema + "." + tableName
+		}
+		if owner, exists := owners[tableName]; exists {
+			return ErrTableNameCollision(tableName, owner, modelName)
+		}
+		owners[tableName] = modelName
+	}
+	return nil
+}
+
+// getTableSchema returns the database schema for a model's table
+func getTableSchema(modelName string, config SQLAlchemyConfig) string {
+	if schema := getTableConfig(modelName, config).Schema; schema != "" {
+		return schema
+	}
+	return config.DefaultSchema
+}
+
+// validateTableConfigs checks that all table and column overrides name models and fields in the registry
+func validateTableConfigs(config SQLAlchemyConfig, r *registry.Registry) error {
+	var modelNames []string
+	for modelName := range config.Tables {
+		modelNames = append(modelNames, modelName)
+	}
+	sort.Strings(modelNames)
+
+	for _, modelName := range modelNames {
+		if _, err := r.GetModel(modelName); err != nil {
+			return ErrTableConfigModelNotFound(modelName)
+		}
+	}
+
+	// Foreign keys of generated tables can't reference tables that aren't generated
+	if err := validateSkippedReferences(config, r); err != nil {
+		return err
+	}
+
+	modelNames = nil
+	for modelName := range config.ColumnNames {
+		modelNames = append(modelNames, modelName)
+	}
+	sort.Strings(modelNames)
+
+	for _, modelName := range modelNames {
+		model, err := r.GetModel(modelName)
+		if err != nil {
+			return ErrTableConfigModelNotFound(modelName)
+		}
+		for fieldName := range config.ColumnNames[modelName] {
+			if _, exists := model.Fields[fieldName]; !exists {
+				return ErrColumnConfigFieldNotFound(modelName, fieldName)
+			}
+		}
+	}
+
+	modelNames = nil
+	for modelName := range config.StructureStorageFields {
+		modelNames = append(modelNames, modelName)
+	}
+	sort.Strings(modelNames)
+
+	for _, modelName := range modelNames {
+		model, err := r.GetModel(modelName)
+		if err != nil {
+			return ErrTableConfigModelNotFound(modelName)
+		}
+		for fieldName := range config.StructureStorageFields[modelName] {
+			field, exists := model.Fields[fieldName]
+			if !exists {
+				return ErrColumnConfigFieldNotFound(modelName, fieldName)
+			}
+			if resolveFieldType(string(field.Type), r) != "structure" {
+				return ErrStorageConfigNotStructure(modelName, fieldName)
+			}
+		}
+	}
+	return nil
+}
+
+// validateSkippedReferences checks that no generated model has a ForOne relation to a skipped model
+func validateSkippedReferences(config SQLAlchemyConfig, r *registry.Registry) error {
+	allModels := r.GetAllModels()
+	var modelNames []string
+	for modelName := range allModels {
+		modelNames = append(modelNames, modelName)
+	}
+	sort.Strings(modelNames)
+
+	for _, modelName := range modelNames {
+		if getTableConfig(modelName, config).Skip {
+			continue
+		}
+		model := allModels[modelName]
+		var relatedNames []string
+		for relatedName := range model.Related {
+			relatedNames = append(relatedNames, relatedName)
+		}
+		sort.Strings(relatedNames)
+
+		for _, relatedName := range relatedNames {
+			relation := model.Related[relatedName]
+			relationType := string(relation.Type)
+			if !yamlops.IsRelationFor(relationType) || !yamlops.IsRelationOne(relationType) || yamlops.IsRelationPoly(relationType) {
+				continue
+			}
+			targetName := yamlops.GetRelationTargetName(relatedName, relation.Aliased)
+			if getTableConfig(targetName, config).Skip {
+				return ErrForeignKeyToSkippedModel(modelName, relatedName, targetName)
+			}
+		}
+	}
+	return nil
+}
+
+// getStructureStorage returns how a structure-typed model field is stored
+func getStructureStorage(modelName string, fieldName string, config SQLAlchemyConfig) string {
+	if storage := config.StructureStorageFields[modelName][fieldName]; storage != "" {
+		return storage
+	}
+	if config.StructureStorage != "" {
+		return config.StructureStorage
+	}
+	return StructureStorageComposite
+}
+
+// compileCompositeColumns returns the columns a structure-typed model field is stored in
+func compileCompositeColumns(modelName string, fieldName string, structureName string, config SQLAlchemyConfig, r *registry.Registry) ([]formatdef.Column, error) {
+	structure, err := r.GetStructure(structureName)
+	if err != nil {
+		return nil, ErrInvalidFieldType(structureName)
+	}
+
+	// Sorted to match the structure's constructor and __composite_values__ order
+	var structureFieldNames []string
+	for name := range structure.Fields {
+		structureFieldNames = append(structureFieldNames, name)
+	}
+	sort.Strings(structureFieldNames)
+
+	prefix := getColumnName(modelName, fieldName, config)
+	var columns []formatdef.Column
+	for _, structureFieldName := range structureFieldNames {
+		structureField := structure.Fields[structureFieldName]
+		modelFieldType := yaml.ModelFieldType(structureField.Type)
+
+		column := formatdef.Column{
+			Name:      getCompositeColumnName(prefix, structureFieldName, config),
+			Attribute: getAttributeName(fieldName) + "_" + formatdef.ToSnakeCase(structureFieldName),
+			Field:     fieldName,
+			Composite: structureName,
+			Type:      mapFieldTypeToSQLAlchemy(typemap.GetFieldType(modelFieldType)),
+			Nullable:  typemap.GetFieldType(modelFieldType).IsNullable(),
+		}
+		switch resolveFieldType(string(structureField.Type), r) {
+		case "enum":
+			column.Type = "Enum"
+			column.EnumName = string(structureField.Type)
+		case "structure", "model":
+			return nil, fmt.Errorf("field %s of structure %s cannot be stored in a column of model %s: nested %s", structureFieldName, structureName, modelName, structureField.Type)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// getCompositeColumnName returns the column name for one field of a composite
+func getCompositeColumnName(prefix string, structureFieldName string, config SQLAlchemyConfig) string {
+	if config.ColumnNaming == ColumnNamingPreserve {
+		return prefix + structureFieldName
+	}
+	return prefix + "_" + formatdef.ToSnakeCase(structureFieldName)
+}
+
+// getColumnName returns the database column name for a model field
+func getColumnName(modelName string, fieldName string, config SQLAlchemyConfig) string {
+	if columnName, exists := config.ColumnNames[modelName][fieldName]; exists {
+		return columnName
+	}
+	if config.ColumnNaming == ColumnNamingPreserve {
+		return fieldName
+	}
+	return formatdef.ToSnakeCase(fieldName)
+}
+
+// getRelationColumnName returns the database column name for a relation's id or type column
+func getRelationColumnName(relatedName string, suffix string, config SQLAlchemyConfig) string {
+	if config.ColumnNaming == ColumnNamingPreserve {
+		if suffix == "id" {
+			return relatedName + "ID"
+		}
+		return relatedName + strings.ToUpper(suffix[:1]) + suffix[1:]
+	}
+	return formatdef.ToSnakeCase(relatedName) + "_" + suffix
+}
+
+// getAttributeName returns the Python attribute name for a model field
+func getAttributeName(fieldName string) string {
+	return SanitizePythonIdentifier(formatdef.ToSnakeCase(fieldName))
+}
+
+// getCollectionName returns the attribute name of a to-many relation, inflected to the plural
+func getCollectionName(relatedName string, config SQLAlchemyConfig) string {
+	return SanitizePythonIdentifier(formatdef.Pluralize(formatdef.ToSnakeCase(relatedName), config.Irregulars))
+}
+
+// getRelationshipName returns the model attribute of a relationship. To-many relationships
+// are named in the plural along with the tables under the snake_plural strategy, so models
+// generated with the other strategies keep their relation names.
+func getRelationshipName(relatedName string, relationType string, config SQLAlchemyConfig) string {
+	if config.TableNaming == TableNamingSnakePlural && yamlops.IsRelationMany(relationType) {
+		return getCollectionName(relatedName, config)
+	}
+	return getAttributeName(relatedName)
+}
+
+// getForeignKeyName returns the constraint name for a foreign key
+func getForeignKeyName(table *formatdef.Table, columnName string, refTableName string, refColumnName string, config SQLAlchemyConfig) string {
+	return applyNamingConvention(getNamingConvention(config)["fk"], table, []string{columnName}, refTableName, []string{refColumnName})
+}
+
+// getUniqueName returns the constraint name for a unique constraint
+func getUniqueName(table *formatdef.Table, columnNames []string, config SQLAlchemyConfig) string {
+	return applyNamingConvention(getNamingConvention(config)["uq"], table, columnNames, "", nil)
+}
+
+// getIndexName returns the name for an index
+func getIndexName(table *formatdef.Table, columnNames []string, config SQLAlchemyConfig) string {
+	return applyNamingConvention(getNamingConvention(config)["ix"], table, columnNames, "", nil)
+}
+
+// getPrimaryKeyName returns the constraint name for a primary key
+func getPrimaryKeyName(table *formatdef.Table, columnNames []string, config SQLAlchemyConfig) string {
+	return applyNamingConvention(getNamingConvention(config)["pk"], table, columnNames, "", nil)
+}
+
+// getPrimaryKeyColumn returns the column definition of a model's primary key
+func getPrimaryKeyColumn(model yaml.Model, config SQLAlchemyConfig, r *registry.Registry) (formatdef.Column, error) {
+	primaryId, exists := model.Identifiers["primary"]
+	if !exists || len(primaryId.Fields) == 0 {
+		return formatdef.Column{}, fmt.Errorf("model %s has no primary identifier", model.Name)
+	}
+
+	fieldName := primaryId.Fields[0]
+	field, exists := model.Fields[fieldName]
+	if !exists {
+		return formatdef.Column{}, fmt.Errorf("primary identifier field %s not found in model %s", fieldName, model.Name)
+	}
+
+	sqlType := mapFieldTypeToSQLAlchemy(typemap.GetFieldType(field.Type))
+	if resolveFieldType(string(field.Type), r) == "enum" {
+		sqlType = "String"
+	}
+
+	return formatdef.Column{
+		Name:       getColumnName(model.Name, fieldName, config),
+		Attribute:  getAttributeName(fieldName),
+		Field:      fieldName,
+		Type:       sqlType,
+		PrimaryKey: true,
+	}, nil
+}
+
+// getPolymorphicIdType returns the shared primary key type of polymorphic targets
+func getPolymorphicIdType(targets []string, config SQLAlchemyConfig, r *registry.Registry) (string, error) {
+	idType := ""
+	for _, targetName := range targets {
+		targetModel, err := r.GetModel(targetName)
+		if err != nil {
+			return "", ErrModelNotFound(targetName)
+		}
+		targetPk, err := getPrimaryKeyColumn(targetModel, config, r)
+		if err != nil {
+			return "", err
+		}
+		if idType != "" && idType != targetPk.Type {
+			// Mixed key types can only be stored as strings
+			return "String", nil
+		}
+		idType = targetPk.Type
+	}
+	if idType == "" {
+		return "String", nil
+	}
+	return idType, nil
+}
+
+// compileEnumDef converts a Morphe enum to its database enum type
+func compileEnumDef(enum yaml.Enum) formatdef.EnumDef {
+	var entryNames []string
+	for name := range enum.Entries {
+		entryNames = append(entryNames, name)
+	}
+	sort.Strings(entryNames)
+
+	// SQLAlchemy persists Python enum member names, not values
+	members := make([]string, 0, len(entryNames))
+	for _, entryName := range entryNames {
+		members = append(members, getEnumMemberName(entryName))
+	}
+
+	return formatdef.EnumDef{
+		Name:     enum.Name,
+		TypeName: strings.ToLower(enum.Name),
+		Members:  members,
+	}
+}
@@ -0,0 +1,310 @@
+package compile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/internal/testutils"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+type CompileTablesTestSuite struct {
+	suite.Suite
+
+	TestDirPath    string
+	RegistryConfig rcfg.MorpheLoadRegistryConfig
+	Registry       *registry.Registry
+}
+
+func TestCompileTablesTestSuite(t *testing.T) {
+	suite.Run(t, new(CompileTablesTestSuite))
+}
+
+func (suite *CompileTablesTestSuite) SetupTest() {
+	suite.TestDirPath = testutils.GetTestDirPath()
+	registryDirPath := filepath.Join(suite.TestDirPath, "registry", "minimal")
+	suite.RegistryConfig = rcfg.MorpheLoadRegistryConfig{
+		RegistryEnumsDirPath:      filepath.Join(registryDirPath, "enums"),
+		RegistryStructuresDirPath: filepath.Join(registryDirPath, "structures"),
+		RegistryModelsDirPath:     filepath.Join(registryDirPath, "models"),
+		RegistryEntitiesDirPath:   filepath.Join(registryDirPath, "entities"),
+	}
+
+	r, err := registry.LoadMorpheRegistry(registry.LoadMorpheRegistryHooks{}, suite.RegistryConfig)
+	suite.Require().NoError(err)
+	suite.Registry = r
+}
+
+func (suite *CompileTablesTestSuite) compileSchema(config compile.SQLAlchemyConfig) *formatdef.Schema {
+	schema, err := compile.CompileSchema(compile.MorpheCompileConfig{FormatConfig: config}, suite.Registry)
+	suite.Require().NoError(err)
+	return schema
+}
+
+func (suite *CompileTablesTestSuite) compileWithConfig(config compile.SQLAlchemyConfig) error {
+	workingDirPath := filepath.Join(suite.TestDirPath, "working")
+	suite.Require().NoError(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	return compile.MorpheToSQLAlchemy(compile.MorpheCompileConfig{
+		MorpheLoadRegistryConfig: suite.RegistryConfig,
+		OutputPath:               workingDirPath,
+		FormatConfig:             config,
+	})
+}
+
+func (suite *CompileTablesTestSuite) TestCompileTable_Defaults() {
+	person, err := suite.Registry.GetModel("Person")
+	suite.Require().NoError(err)
+
+	table, err := compile.CompileTable(person, compile.SQLAlchemyConfig{}, suite.Registry)
+
+	suite.NoError(err)
+	suite.Equal("person", table.Name)
+	suite.Empty(table.Schema)
+	suite.Empty(table.Comment)
+	suite.Equal("person", table.QualifiedName())
+	suite.Equal([]formatdef.ForeignKey{
+		{Name: "fk_person_company_id_company", Column: "company_id", RefTable: "company", RefColumn: "id"},
+	}, table.ForeignKeys)
+}
+
+func (suite *CompileTablesTestSuite) TestCompileTable_TableConfig() {
+	config := compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"Person": {Name: "people", Schema: "crm", Comment: "Contact persons"},
+		},
+	}
+	person, err := suite.Registry.GetModel("Person")
+	suite.Require().NoError(err)
+
+	table, err := compile.CompileTable(person, config, suite.Registry)
+
+	suite.NoError(err)
+	suite.Equal("people", table.Name)
+	suite.Equal("crm", table.Schema)
+	suite.Equal("Contact persons", table.Comment)
+	suite.Equal("crm.people", table.QualifiedName())
+}
+
+func (suite *CompileTablesTestSuite) TestCompileTable_NameOverrideIgnoresPrefix() {
+	config := compile.SQLAlchemyConfig{
+		TableNamePrefix: "app_",
+		Tables: map[string]cfg.TableConfig{
+			"Person": {Name: "people"},
+		},
+	}
+
+	schema := suite.compileSchema(config)
+
+	_, exists := schema.GetTable("people")
+	suite.True(exists)
+	_, exists = schema.GetTable("app_company")
+	suite.True(exists)
+}
+
+func (suite *CompileTablesTestSuite) TestCompileSchema_DefaultSchema() {
+	config := compile.SQLAlchemyConfig{
+		DefaultSchema: "app",
+		Tables: map[string]cfg.TableConfig{
+			"Person": {Schema: "crm"},
+		},
+	}
+
+	schema := suite.compileSchema(config)
+
+	company, exists := schema.GetTable("app.company")
+	suite.True(exists)
+	suite.Equal("app", company.Schema)
+	person, exists := schema.GetTable("crm.person")
+	suite.True(exists)
+	suite.Equal("crm", person.Schema)
+}
+
+func (suite *CompileTablesTestSuite) TestCompileSchema_SchemaQualifiedForeignKeys() {
+	config := compile.SQLAlchemyConfig{
+		DefaultSchema: "app",
+		Tables: map[string]cfg.TableConfig{
+			"Person": {Name: "people", Schema: "crm"},
+		},
+	}
+
+	schema := suite.compileSchema(config)
+
+	person, exists := schema.GetTable("crm.people")
+	suite.Require().True(exists)
+	companyFk, exists := person.GetForeignKey("company_id")
+	suite.True(exists)
+	suite.Equal("app", companyFk.RefSchema)
+	suite.Equal("app.company", companyFk.QualifiedRefTable())
+
+	contactInfo, exists := schema.GetTable("app.contact_info")
+	suite.Require().True(exists)
+	personFk, exists := contactInfo.GetForeignKey("person_id")
+	suite.True(exists)
+	suite.Equal("crm.people", personFk.QualifiedRefTable())
+	suite.Equal("id", personFk.RefColumn)
+}
+
+func (suite *CompileTablesTestSuite) TestCompileSchema_SkipsTables() {
+	config := compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"ContactInfo": {Skip: true},
+		},
+	}
+
+	schema := suite.compileSchema(config)
+
+	var tableNames []string
+	for _, table := range schema.Tables {
+		tableNames = append(tableNames, table.Name)
+	}
+	suite.Equal([]string{"company", "person"}, tableNames)
+}
+
+func (suite *CompileTablesTestSuite) TestMorpheToSQLAlchemy_TableConfigUnknownModel() {
+	config := compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"Invoice": {Name: "invoices"},
+		},
+	}
+
+	err := suite.compileWithConfig(config)
+
+	suite.ErrorIs(err, compile.ErrInvalidTableConfig)
+	suite.ErrorContains(err, "unknown model Invoice")
+}
+
+func (suite *CompileTablesTestSuite) TestMorpheToSQLAlchemy_ForeignKeyToSkippedModel() {
+	config := compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"Company": {Skip: true},
+		},
+	}
+
+	err := suite.compileWithConfig(config)
+
+	suite.ErrorIs(err, compile.ErrInvalidTableConfig)
+	suite.ErrorContains(err, "relation Person.Company references skipped model Company")
+}
+
+func (suite *CompileTablesTestSuite) TestCompileSchema_TableNaming() {
+	tests := []struct {
+		tableNaming string
+		irregulars  map[string]string
+		expected    map[string]string
+	}{
+		{compile.TableNamingSnake, nil, map[string]string{"Company": "company", "ContactInfo": "contact_info", "Person": "person"}},
+		// Irregular and uncountable words pluralize by their last word
+		{compile.TableNamingSnakePlural, nil, map[string]string{"Company": "companies", "ContactInfo": "contact_info", "Person": "people"}},
+		{compile.TableNamingSnakePlural, map[string]string{"company": "firms", "info": "infos"}, map[string]string{"Company": "firms", "ContactInfo": "contact_infos", "Person": "people"}},
+		{compile.TableNamingPreserve, nil, map[string]string{"Company": "Company", "ContactInfo": "ContactInfo", "Person": "Person"}},
+	}
+
+	for _, test := range tests {
+		schema := suite.compileSchema(compile.SQLAlchemyConfig{TableNaming: test.tableNaming, Irregulars: test.irregulars})
+
+		tableNames := make(map[string]string)
+		for _, table := range schema.Tables {
+			tableNames[table.ModelName] = table.Name
+		}
+		suite.Equal(test.expected, tableNames, test.tableNaming)
+
+		// Foreign keys reference the tables by their converted names
+		person, exists := schema.GetTable(test.expected["Person"])
+		suite.Require().True(exists)
+		companyFk, exists := person.GetForeignKey("company_id")
+		suite.True(exists)
+		suite.Equal(test.expected["Company"], companyFk.RefTable, test.tableNaming)
+		contactInfo, exists := schema.GetTable(test.expected["ContactInfo"])
+		suite.Require().True(exists)
+		personFk, exists := contactInfo.GetForeignKey("person_id")
+		suite.True(exists)
+		suite.Equal(test.expected["Person"], personFk.RefTable, test.tableNaming)
+	}
+}
+
+func (suite *CompileTablesTestSuite) TestCompileSchema_TableNamingWithAffixes() {
+	config := compile.SQLAlchemyConfig{
+		TableNaming:     compile.TableNamingSnakePlural,
+		TableNamePrefix: "app_",
+		TableNameSuffix: "_v2",
+	}
+
+	schema := suite.compileSchema(config)
+
+	// The model name is pluralized before the prefix and suffix are added
+	_, exists := schema.GetTable("app_people_v2")
+	suite.True(exists)
+	_, exists = schema.GetTable("app_companies_v2")
+	suite.True(exists)
+}
+
+func (suite *CompileTablesTestSuite) TestMorpheToSQLAlchemy_TableNameCollision() {
+	tests := []struct {
+		config   compile.SQLAlchemyConfig
+		expected string
+	}{
+		{
+			compile.SQLAlchemyConfig{
+				TableNaming: compile.TableNamingSnakePlural,
+				Irregulars:  map[string]string{"company": "people"},
+			},
+			"models Company and Person both map to table people",
+		},
+		{
+			compile.SQLAlchemyConfig{
+				Tables: map[string]cfg.TableConfig{"Company": {Name: "person"}},
+			},
+			"models Company and Person both map to table person",
+		},
+		{
+			compile.SQLAlchemyConfig{
+				DefaultSchema: "crm",
+				Tables:        map[string]cfg.TableConfig{"Company": {Name: "person"}},
+			},
+			"models Company and Person both map to table crm.person",
+		},
+	}
+
+	for _, test := range tests {
+		err := suite.compileWithConfig(test.config)
+
+		suite.ErrorIs(err, compile.ErrInvalidTableConfig)
+		suite.ErrorContains(err, test.expected)
+	}
+}
+
+func (suite *CompileTablesTestSuite) TestMorpheToSQLAlchemy_SameTableNameInOtherSchema() {
+	config := compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"Company": {Name: "person", Schema: "crm"},
+		},
+	}
+
+	suite.NoError(suite.compileWithConfig(config))
+}
+
+func (suite *CompileTablesTestSuite) TestMorpheToSQLAlchemy_SkippedModelSharesTableName() {
+	config := compile.SQLAlchemyConfig{
+		Tables: map[string]cfg.TableConfig{
+			"ContactInfo": {Name: "person", Skip: true},
+		},
+	}
+
+	suite.NoError(suite.compileWithConfig(config))
+
+	// The same name is still a collision once the model is generated
+	config.Tables["ContactInfo"] = cfg.TableConfig{Name: "person"}
+	err := suite.compileWithConfig(config)
+
+	suite.ErrorIs(err, compile.ErrInvalidTableConfig)
+	suite.ErrorContains(err, "models ContactInfo and Person both map to table person")
+}
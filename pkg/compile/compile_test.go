@@ -1,8 +1,10 @@
 package compile_test
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -11,6 +13,8 @@ import (
 	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
 	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/internal/testutils"
 	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
 )
 
 type CompileTestSuite struct {
@@ -43,6 +47,25 @@ func (suite *CompileTestSuite) TearDownTest() {
 	suite.TestDirPath = ""
 }
 
+// newCompileConfig returns the config compiling the minimal registry to outputPath
+func (suite *CompileTestSuite) newCompileConfig(outputPath string) compile.MorpheCompileConfig {
+	return compile.MorpheCompileConfig{
+		MorpheLoadRegistryConfig: rcfg.MorpheLoadRegistryConfig{
+			RegistryEnumsDirPath:      suite.EnumsDirPath,
+			RegistryStructuresDirPath: suite.StructuresDirPath,
+			RegistryModelsDirPath:     suite.ModelsDirPath,
+			RegistryEntitiesDirPath:   suite.EntitiesDirPath,
+		},
+		OutputPath: outputPath,
+		FormatConfig: compile.SQLAlchemyConfig{
+			UseDeclarative: true,
+			AddTypeHints:   true,
+			IndentSize:     4,
+			PythonVersion:  "3.8",
+		},
+	}
+}
+
 func (suite *CompileTestSuite) TestMorpheToSQLAlchemy() {
 	workingDirPath := suite.TestDirPath + "/working"
 	suite.Nil(os.Mkdir(workingDirPath, 0755))
@@ -135,6 +158,11 @@ func (suite *CompileTestSuite) TestMorpheToSQLAlchemy() {
 	suite.FileExists(structurePath0)
 	suite.FileEquals(structurePath0, gtStructurePath0)
 
+	structurePath1 := structuresDirPath + "/delivery.py"
+	gtStructurePath1 := gtStructuresDirPath + "/delivery.py"
+	suite.FileExists(structurePath1)
+	suite.FileEquals(structurePath1, gtStructurePath1)
+
 	// Check entities
 	entitiesDirPath := workingDirPath + "/entities"
 	gtEntitiesDirPath := suite.TestGroundTruthDirPath + "/entities"
@@ -158,6 +186,281 @@ func (suite *CompileTestSuite) TestMorpheToSQLAlchemy() {
 	suite.FileEquals(entityPath1, gtEntityPath1)
 }
 
+func (suite *CompileTestSuite) TestMorpheToSQLAlchemy_SingleFile() {
+	workingDirPath := suite.TestDirPath + "/working-single-file"
+	suite.Nil(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	config := suite.newCompileConfig(workingDirPath)
+	config.FormatConfig.SingleFile = true
+	config.MorpheConfig.Entities = cfg.EntityConfig{IncludeValidation: true}
+
+	compileErr := compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+
+	for _, category := range []string{"models", "enums", "structures", "entities"} {
+		suite.FileExists(filepath.Join(workingDirPath, category+".py"))
+		suite.NoDirExists(filepath.Join(workingDirPath, category))
+	}
+
+	models, err := os.ReadFile(filepath.Join(workingDirPath, "models.py"))
+	suite.NoError(err)
+	suite.Contains(string(models), "from .enums import Nationality\n")
+	suite.Contains(string(models), "from .structures import Address\n")
+	suite.NotContains(string(models), "from .person import")
+	suite.NotContains(string(models), "from ..")
+
+	// The validation error is defined before the entities using it
+	entities, err := os.ReadFile(filepath.Join(workingDirPath, "entities.py"))
+	suite.NoError(err)
+	suite.NotContains(string(entities), "from .errors import")
+	suite.Less(strings.Index(string(entities), "class EntityValidationError"), strings.Index(string(entities), "class Company"))
+	suite.Equal(1, strings.Count(string(entities), "# Code generated by Morphe"))
+}
+
+func (suite *CompileTestSuite) TestMorpheToSQLAlchemy_Check() {
+	workingDirPath := suite.TestDirPath + "/working-check"
+	suite.Nil(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	config := suite.newCompileConfig(workingDirPath)
+
+	compileErr := compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+
+	config.Check = true
+	suite.NoError(compile.MorpheToSQLAlchemy(config))
+
+	companyPath := filepath.Join(workingDirPath, "models", "company.py")
+	suite.Nil(os.WriteFile(companyPath, []byte("# edited\n"), 0644))
+	suite.Nil(os.Remove(filepath.Join(workingDirPath, "enums", "nationality.py")))
+
+	checkErr := compile.MorpheToSQLAlchemy(config)
+	suite.ErrorIs(checkErr, compile.ErrStaleOutput)
+	suite.ErrorContains(checkErr, "2 files differ")
+
+	// Nothing is written in check mode
+	company, err := os.ReadFile(companyPath)
+	suite.NoError(err)
+	suite.Equal("# edited\n", string(company))
+	suite.NoFileExists(filepath.Join(workingDirPath, "enums", "nationality.py"))
+}
+
+func (suite *CompileTestSuite) TestMorpheToSQLAlchemy_PruneStaleFiles() {
+	workingDirPath := suite.TestDirPath + "/working-prune"
+	suite.Nil(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	config := suite.newCompileConfig(workingDirPath)
+
+	compileErr := compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+	suite.FileExists(filepath.Join(workingDirPath, compile.ManifestFileName))
+
+	entitiesDirPath := filepath.Join(workingDirPath, "entities")
+	suite.Nil(os.WriteFile(filepath.Join(entitiesDirPath, "person.py"), []byte("# edited\n"), 0644))
+	suite.Nil(os.WriteFile(filepath.Join(entitiesDirPath, "notes.txt"), []byte("notes\n"), 0644))
+
+	// The entities are no longer part of the registry
+	config.RegistryEntitiesDirPath = filepath.Join(workingDirPath, "no-entities")
+	compileErr = compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+
+	suite.NoFileExists(filepath.Join(entitiesDirPath, "company.py"))
+	suite.NoFileExists(filepath.Join(entitiesDirPath, "__init__.py"))
+	suite.FileExists(filepath.Join(entitiesDirPath, "person.py"))
+	suite.FileExists(filepath.Join(entitiesDirPath, "notes.txt"))
+	suite.FileExists(filepath.Join(workingDirPath, "models", "person.py"))
+
+	manifest, err := os.ReadFile(filepath.Join(workingDirPath, compile.ManifestFileName))
+	suite.NoError(err)
+	suite.Contains(string(manifest), `"path": "models/person.py"`)
+	suite.NotContains(string(manifest), `"path": "entities/`)
+}
+
+func (suite *CompileTestSuite) TestMorpheToSQLAlchemy_CustomRegions() {
+	workingDirPath := suite.TestDirPath + "/working-custom-regions"
+	suite.Nil(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	config := suite.newCompileConfig(workingDirPath)
+
+	compileErr := compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+
+	personPath := filepath.Join(workingDirPath, "models", "person.py")
+	person, err := os.ReadFile(personPath)
+	suite.NoError(err)
+	customCode := "    def greeting(self) -> str:\n        return 'Hello ' + self.first_name\n"
+	edited := strings.Replace(string(person), "    # morphe:custom-begin\n", "    # morphe:custom-begin\n"+customCode, 1)
+	suite.Nil(os.WriteFile(personPath, []byte(edited), 0644))
+
+	// Code in custom regions is carried over
+	compileErr = compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+	person, err = os.ReadFile(personPath)
+	suite.NoError(err)
+	suite.Equal(edited, string(person))
+
+	// Changes outside custom regions aren't overwritten unless forced
+	suite.Nil(os.WriteFile(personPath, []byte(edited+"\nEXTRA = 1\n"), 0644))
+	compileErr = compile.MorpheToSQLAlchemy(config)
+	suite.ErrorIs(compileErr, compile.ErrModifiedOutput)
+
+	config.Force = true
+	compileErr = compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+	person, err = os.ReadFile(personPath)
+	suite.NoError(err)
+	suite.Equal(edited, string(person))
+}
+
+func (suite *CompileTestSuite) TestMorpheToSQLAlchemy_FailedCompileWritesNothing() {
+	workingDirPath := suite.TestDirPath + "/working-failed-compile"
+	suite.Nil(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	config := suite.newCompileConfig(workingDirPath)
+
+	compileErr := compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+	before, err := os.ReadFile(filepath.Join(workingDirPath, "models", "company.py"))
+	suite.NoError(err)
+	entriesBefore, err := os.ReadDir(workingDirPath)
+	suite.NoError(err)
+
+	// Enums and models compile with the new naming, then the migrations fail
+	config.FormatConfig.TableNaming = compile.TableNamingSnakePlural
+	config.Migrations = cfg.MigrationConfig{
+		Enabled:            true,
+		PreviousSchemaPath: filepath.Join(workingDirPath, "missing-schema.json"),
+	}
+	compileErr = compile.MorpheToSQLAlchemy(config)
+	suite.ErrorContains(compileErr, "failed to compile migrations")
+
+	after, err := os.ReadFile(filepath.Join(workingDirPath, "models", "company.py"))
+	suite.NoError(err)
+	suite.Equal(string(before), string(after))
+	entriesAfter, err := os.ReadDir(workingDirPath)
+	suite.NoError(err)
+	suite.Equal(len(entriesBefore), len(entriesAfter))
+	suite.NoDirExists(filepath.Join(workingDirPath, "migrations"))
+}
+
+func (suite *CompileTestSuite) TestMorpheToSQLAlchemy_FailedCommitRestoresOutput() {
+	workingDirPath := suite.TestDirPath + "/working-failed-commit"
+	suite.Nil(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	config := suite.newCompileConfig(workingDirPath)
+
+	compileErr := compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+	before, err := os.ReadFile(filepath.Join(workingDirPath, "models", "company.py"))
+	suite.NoError(err)
+	manifestBefore, err := os.ReadFile(filepath.Join(workingDirPath, compile.ManifestFileName))
+	suite.NoError(err)
+
+	// schema.sql is renamed last, after the models were already replaced
+	suite.Nil(os.MkdirAll(filepath.Join(workingDirPath, "schema.sql", "keep"), 0755))
+	config.FormatConfig.TableNaming = compile.TableNamingSnakePlural
+	config.DDL = cfg.DDLConfig{Enabled: true}
+	compileErr = compile.MorpheToSQLAlchemy(config)
+	suite.ErrorContains(compileErr, "schema.sql is a directory")
+
+	after, err := os.ReadFile(filepath.Join(workingDirPath, "models", "company.py"))
+	suite.NoError(err)
+	suite.Equal(string(before), string(after))
+	manifestAfter, err := os.ReadFile(filepath.Join(workingDirPath, compile.ManifestFileName))
+	suite.NoError(err)
+	suite.Equal(string(manifestBefore), string(manifestAfter))
+
+	// No temporary or backup files are left behind
+	leftovers, err := filepath.Glob(filepath.Join(workingDirPath, "models", ".*"))
+	suite.NoError(err)
+	suite.Empty(leftovers)
+}
+
+func (suite *CompileTestSuite) readMigration(outputPath string) string {
+	migrationPaths, err := filepath.Glob(filepath.Join(outputPath, "migrations", "*.py"))
+	suite.NoError(err)
+	suite.Len(migrationPaths, 1)
+	content, err := os.ReadFile(migrationPaths[0])
+	suite.NoError(err)
+	return string(content)
+}
+
+func (suite *CompileTestSuite) TestMorpheToSQLAlchemy_MigrationCreatesEnumTypesOnce() {
+	workingDirPath := suite.TestDirPath + "/working"
+	suite.Nil(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	config := suite.newCompileConfig(workingDirPath)
+	config.Migrations = cfg.MigrationConfig{Enabled: true}
+
+	compileErr := compile.MorpheToSQLAlchemy(config)
+	suite.NoError(compileErr)
+
+	migration := suite.readMigration(workingDirPath)
+	upgrade, downgrade, found := strings.Cut(migration, "def downgrade():")
+	suite.True(found)
+	suite.Equal(1, strings.Count(upgrade, "sa.Enum('DE', 'FR', 'US', name='nationality').create(op.get_bind(), checkfirst=True)"))
+	suite.Contains(upgrade, "sa.Column('nationality', postgresql.ENUM('DE', 'FR', 'US', name='nationality', create_type=False), nullable=False)")
+	suite.Contains(migration, "from sqlalchemy.dialects import postgresql")
+
+	// The type outlives its tables, so it is dropped after them
+	dropTable := strings.Index(downgrade, "op.drop_table('person')")
+	dropEnum := strings.Index(downgrade, "sa.Enum(name='nationality').drop(op.get_bind(), checkfirst=True)")
+	suite.NotEqual(-1, dropTable)
+	suite.Greater(dropEnum, dropTable)
+}
+
+func (suite *CompileTestSuite) TestMorpheToSQLAlchemy_MigrationEnumMembers() {
+	workingDirPath := suite.TestDirPath + "/working"
+	suite.Nil(os.Mkdir(workingDirPath, 0755))
+	defer os.RemoveAll(workingDirPath)
+
+	config := suite.newCompileConfig(workingDirPath)
+	config.Migrations = cfg.MigrationConfig{ExportSchema: true}
+	suite.NoError(compile.MorpheToSQLAlchemy(config))
+
+	// The previous snapshot had IT instead of FR
+	snapshot, err := os.ReadFile(filepath.Join(workingDirPath, "schema.json"))
+	suite.NoError(err)
+	var previous formatdef.Schema
+	suite.NoError(json.Unmarshal(snapshot, &previous))
+	suite.Len(previous.Enums, 1)
+	previous.Enums[0].Members = []string{"DE", "IT", "US"}
+	previousSnapshot, err := json.Marshal(previous)
+	suite.NoError(err)
+	previousSchemaPath := filepath.Join(suite.TestDirPath, "previous-schema.json")
+	suite.NoError(os.WriteFile(previousSchemaPath, previousSnapshot, 0644))
+	defer os.Remove(previousSchemaPath)
+
+	config.Migrations = cfg.MigrationConfig{Enabled: true, PreviousSchemaPath: previousSchemaPath}
+
+	// PostgreSQL alters the enum type
+	suite.NoError(compile.MorpheToSQLAlchemy(config))
+	migration := suite.readMigration(workingDirPath)
+	upgrade, _, _ := strings.Cut(migration, "def downgrade():")
+	suite.Contains(upgrade, "op.execute(\"ALTER TYPE nationality ADD VALUE IF NOT EXISTS 'FR'\")")
+	suite.Contains(upgrade, `op.execute("""ALTER TYPE nationality RENAME TO nationality_old""")`)
+	suite.Contains(upgrade, "sa.Enum('DE', 'FR', 'US', name='nationality').create(op.get_bind())")
+	suite.Contains(upgrade, `op.execute("""ALTER TABLE person ALTER COLUMN nationality TYPE nationality USING nationality::text::nationality""")`)
+	suite.Contains(upgrade, `op.execute("""DROP TYPE nationality_old""")`)
+	suite.NoError(os.RemoveAll(filepath.Join(workingDirPath, "migrations")))
+
+	// The other dialects store enums inline and alter the columns instead
+	config.DDL = cfg.DDLConfig{Dialect: "sqlite"}
+	suite.NoError(compile.MorpheToSQLAlchemy(config))
+	migration = suite.readMigration(workingDirPath)
+	upgrade, downgrade, _ := strings.Cut(migration, "def downgrade():")
+	suite.NotContains(migration, "ALTER TYPE")
+	suite.Equal(1, strings.Count(upgrade, "with op.batch_alter_table('person') as batch_op:"))
+	suite.Contains(upgrade, "batch_op.alter_column('nationality', type_=sa.Enum('DE', 'FR', 'US', name='nationality'), existing_type=sa.Enum('DE', 'IT', 'US', name='nationality'), existing_nullable=False)")
+	suite.Contains(downgrade, "batch_op.alter_column('nationality', type_=sa.Enum('DE', 'IT', 'US', name='nationality'), existing_type=sa.Enum('DE', 'FR', 'US', name='nationality'), existing_nullable=False)")
+}
+
 // TestGroundTruthRegeneration ensures ground truth can be regenerated consistently
 func (suite *CompileTestSuite) TestGroundTruthRegeneration() {
 	// This test verifies that the ground truth files match current generation
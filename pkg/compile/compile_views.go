@@ -0,0 +1,399 @@
+package compile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	"github.com/kalo-build/morphe-go/pkg/yaml"
+	"github.com/kalo-build/morphe-go/pkg/yamlops"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+// CompileViews compiles the database views of the entities configured as views.
+// Entities missing from the registry are skipped, so older registries can be compiled for diffs.
+func CompileViews(config MorpheCompileConfig, r *registry.Registry) ([]formatdef.View, error) {
+	entityNames := append([]string(nil), config.MorpheConfig.Entities.Views...)
+	sort.Strings(entityNames)
+
+	var views []formatdef.View
+	for _, entityName := range entityNames {
+		entity, err := r.GetEntity(entityName)
+		if err != nil {
+			continue
+		}
+		view, err := CompileView(entity, config.FormatConfig, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile view for entity %s: %w", entityName, err)
+		}
+		views = append(views, *view)
+	}
+	return views, nil
+}
+
+// validateEntityViews checks that view entities exist and their mapped classes don't shadow a model
+func validateEntityViews(config MorpheCompileConfig, r *registry.Registry) error {
+	for _, entityName := range config.MorpheConfig.Entities.Views {
+		if _, err := r.GetEntity(entityName); err != nil {
+			return fmt.Errorf("view entity not found: %s", entityName)
+		}
+		if _, err := r.GetModel(getViewClassName(entityName)); err == nil {
+			return fmt.Errorf("view class %s of entity %s collides with model %s", getViewClassName(entityName), entityName, getViewClassName(entityName))
+		}
+	}
+	return nil
+}
+
+// getViewName returns the database view name of an entity
+func getViewName(entityName string) string {
+	return formatdef.ToSnakeCase(entityName) + "_view"
+}
+
+// getViewClassName returns the name of the read-only class mapped over an entity's view
+func getViewClassName(entityName string) string {
+	return entityName + "View"
+}
+
+// CompileView converts an entity to the view selecting its fields, joined along the relations of its field paths
+func CompileView(entity yaml.Entity, config SQLAlchemyConfig, r *registry.Registry) (*formatdef.View, error) {
+	var fieldNames []string
+	for name := range entity.Fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+	if len(fieldNames) == 0 {
+		return nil, fmt.Errorf("entity %s has no fields to select", entity.Name)
+	}
+	if len(entity.Identifiers["primary"].Fields) == 0 {
+		return nil, fmt.Errorf("entity %s needs a primary identifier to be mapped over a view", entity.Name)
+	}
+
+	rootModelName := strings.Split(string(entity.Fields[fieldNames[0]].Type), ".")[0]
+	rootModel, err := r.GetModel(rootModelName)
+	if err != nil {
+		return nil, ErrModelNotFound(rootModelName)
+	}
+	rootTable, err := CompileTable(rootModel, config, r)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &formatdef.View{
+		Name:       getViewName(entity.Name),
+		Schema:     rootTable.Schema,
+		EntityName: entity.Name,
+		From:       rootTable.Name,
+		FromSchema: rootTable.Schema,
+	}
+
+	for _, fieldName := range fieldNames {
+		fieldPath := entity.Fields[fieldName].Type
+		if modelName := strings.Split(string(fieldPath), ".")[0]; modelName != rootModelName {
+			return nil, fmt.Errorf("field %s reads model %s, but the entity is based on model %s", fieldName, modelName, rootModelName)
+		}
+		hops, terminalModel, terminalField, err := walkEntityFieldPath(fieldPath, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve field path for %s: %w", fieldName, err)
+		}
+
+		// Every relation is joined once per path, outer joined from the first optional hop on
+		source := rootTable
+		sourceModel := rootModel
+		sourceAlias := rootTable.Name
+		var aliasParts []string
+		outer := false
+		for _, hop := range hops {
+			if yamlops.IsRelationMany(hop.RelationType) || yamlops.IsRelationPoly(hop.RelationType) {
+				return nil, fmt.Errorf("field path %s of %s crosses %s relation %s", fieldPath, fieldName, hop.RelationType, hop.RelationName)
+			}
+			targetModel, err := r.GetModel(hop.TargetModel)
+			if err != nil {
+				return nil, ErrModelNotFound(hop.TargetModel)
+			}
+			target, err := CompileTable(targetModel, config, r)
+			if err != nil {
+				return nil, err
+			}
+
+			outer = outer || !yamlops.IsRelationFor(hop.RelationType)
+			aliasParts = append(aliasParts, formatdef.ToSnakeCase(hop.RelationName))
+			alias := strings.Join(aliasParts, "_")
+			if _, joined := view.GetJoin(alias); !joined {
+				join, err := compileViewJoin(hop, sourceModel, sourceAlias, targetModel, target, config, r)
+				if err != nil {
+					return nil, err
+				}
+				join.Alias = alias
+				join.Outer = outer
+				view.Joins = append(view.Joins, join)
+			}
+			source, sourceModel, sourceAlias = target, targetModel, alias
+		}
+
+		var columns []formatdef.Column
+		for _, column := range source.Columns {
+			if column.Field == terminalField && column.Composite == "" {
+				columns = append(columns, column)
+			}
+		}
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("field %s.%s of %s is not stored in a single column", terminalModel.Name, terminalField, fieldName)
+		}
+
+		column := columns[0]
+		isPrimaryKey := containsString(entity.Identifiers["primary"].Fields, fieldName)
+		view.Columns = append(view.Columns, formatdef.ViewColumn{
+			Column: formatdef.Column{
+				Name:       formatdef.ToSnakeCase(fieldName),
+				Attribute:  SanitizePythonIdentifier(formatdef.ToSnakeCase(fieldName)),
+				Field:      fieldName,
+				Structure:  column.Structure,
+				Type:       column.Type,
+				EnumName:   column.EnumName,
+				Nullable:   !isPrimaryKey && (column.Nullable || outer),
+				PrimaryKey: isPrimaryKey,
+			},
+			Source:       sourceAlias,
+			SourceColumn: column.Name,
+		})
+	}
+
+	// Key columns are selected when the root model has the same relation
+	var relatedNames []string
+	for name := range entity.Related {
+		relatedNames = append(relatedNames, name)
+	}
+	sort.Strings(relatedNames)
+	for _, relatedName := range relatedNames {
+		relationType := string(entity.Related[relatedName].Type)
+		if !yamlops.IsRelationFor(relationType) || !yamlops.IsRelationOne(relationType) {
+			continue
+		}
+		if modelRelation, exists := rootModel.Related[relatedName]; !exists || modelRelation.Type != entity.Related[relatedName].Type {
+			continue
+		}
+		for _, column := range rootTable.Columns {
+			if column.Field != relatedName {
+				continue
+			}
+			view.Columns = append(view.Columns, formatdef.ViewColumn{
+				Column: formatdef.Column{
+					Name:      column.Name,
+					Attribute: column.Attribute,
+					Field:     relatedName,
+					Type:      column.Type,
+					Nullable:  column.Nullable,
+				},
+				Source:       rootTable.Name,
+				SourceColumn: column.Name,
+			})
+		}
+	}
+
+	seenColumns := make(map[string]string)
+	for _, column := range view.Columns {
+		if field, exists := seenColumns[column.Name]; exists {
+			return nil, fmt.Errorf("fields %s and %s of entity %s both map to view column %s", field, column.Field, entity.Name, column.Name)
+		}
+		seenColumns[column.Name] = column.Field
+	}
+
+	return view, nil
+}
+
+// compileViewJoin resolves the join condition of a ForOne or HasOne relation between two tables
+func compileViewJoin(hop entityPathHop, sourceModel yaml.Model, sourceAlias string, targetModel yaml.Model, target *formatdef.Table, config SQLAlchemyConfig, r *registry.Registry) (formatdef.ViewJoin, error) {
+	join := formatdef.ViewJoin{
+		Table:  target.Name,
+		Schema: target.Schema,
+		Source: sourceAlias,
+	}
+
+	// ForOne: the source holds the foreign key to the target's primary key
+	if yamlops.IsRelationFor(hop.RelationType) {
+		targetPk, err := getPrimaryKeyColumn(targetModel, config, r)
+		if err != nil {
+			return join, err
+		}
+		join.Column = targetPk.Name
+		join.SourceColumn = getRelationColumnName(hop.RelationName, "id", config)
+		return join, nil
+	}
+
+	// HasOne: the target holds a foreign key back to the source's primary key
+	var backNames []string
+	for name, relation := range targetModel.Related {
+		relationType := string(relation.Type)
+		if yamlops.IsRelationFor(relationType) && yamlops.IsRelationOne(relationType) && !yamlops.IsRelationPoly(relationType) &&
+			yamlops.GetRelationTargetName(name, relation.Aliased) == sourceModel.Name {
+			backNames = append(backNames, name)
+		}
+	}
+	if len(backNames) == 0 {
+		return join, fmt.Errorf("model %s has no ForOne relation back to %s for relation %s", targetModel.Name, sourceModel.Name, hop.RelationName)
+	}
+	sort.Strings(backNames)
+	sourcePk, err := getPrimaryKeyColumn(sourceModel, config, r)
+	if err != nil {
+		return join, err
+	}
+	join.Column = getRelationColumnName(backNames[0], "id", config)
+	join.SourceColumn = sourcePk.Name
+	return join, nil
+}
+
+// viewRelationship is a viewonly relationship from a view class to a related model
+type viewRelationship struct {
+	Attribute   string
+	Model       string
+	PrimaryJoin string
+	UseList     bool
+}
+
+// compileViewRelationships resolves the relationships of a view class from the entity's relations.
+// Polymorphic relations and relations the root model doesn't share are left out.
+func compileViewRelationships(entity yaml.Entity, view *formatdef.View, config SQLAlchemyConfig, r *registry.Registry) ([]viewRelationship, error) {
+	rootModel, err := r.GetModel(strings.Split(string(entity.Fields[view.Columns[0].Field].Type), ".")[0])
+	if err != nil {
+		return nil, err
+	}
+	className := getViewClassName(entity.Name)
+
+	// Has relations join on the view's single primary key column selected from the root table
+	var viewPk string
+	for _, column := range view.Columns {
+		if column.PrimaryKey {
+			if viewPk != "" || column.Source != view.From {
+				viewPk = ""
+				break
+			}
+			viewPk = column.Attribute
+		}
+	}
+
+	var relatedNames []string
+	for name := range entity.Related {
+		relatedNames = append(relatedNames, name)
+	}
+	sort.Strings(relatedNames)
+
+	var relationships []viewRelationship
+	for _, relatedName := range relatedNames {
+		relation := entity.Related[relatedName]
+		relationType := string(relation.Type)
+		modelRelation, exists := rootModel.Related[relatedName]
+		if yamlops.IsRelationPoly(relationType) || !exists || modelRelation.Type != relation.Type {
+			continue
+		}
+		targetName := yamlops.GetRelationTargetName(relatedName, modelRelation.Aliased)
+		targetModel, err := r.GetModel(targetName)
+		if err != nil {
+			return nil, ErrModelNotFound(targetName)
+		}
+		relationship := viewRelationship{
+			Attribute: getRelationshipName(relatedName, relationType, config),
+			Model:     targetName,
+			UseList:   yamlops.IsRelationMany(relationType),
+		}
+
+		switch {
+		case yamlops.IsRelationFor(relationType) && yamlops.IsRelationOne(relationType):
+			targetPk := targetModel.Identifiers["primary"].Fields
+			if len(targetPk) != 1 {
+				continue
+			}
+			relationship.PrimaryJoin = fmt.Sprintf("foreign(%s.%s) == %s.%s", className, getAttributeName(relatedName+"_id"), targetName, getAttributeName(targetPk[0]))
+		case !yamlops.IsRelationFor(relationType) && viewPk != "":
+			var backNames []string
+			for name, backRelation := range targetModel.Related {
+				backType := string(backRelation.Type)
+				if yamlops.IsRelationFor(backType) && yamlops.IsRelationOne(backType) && !yamlops.IsRelationPoly(backType) &&
+					yamlops.GetRelationTargetName(name, backRelation.Aliased) == rootModel.Name {
+					backNames = append(backNames, name)
+				}
+			}
+			if len(backNames) == 0 {
+				continue
+			}
+			sort.Strings(backNames)
+			relationship.PrimaryJoin = fmt.Sprintf("foreign(%s.%s) == %s.%s", targetName, getAttributeName(backNames[0]+"_id"), className, viewPk)
+		default:
+			continue
+		}
+		relationships = append(relationships, relationship)
+	}
+	return relationships, nil
+}
+
+// generateViewModelContent generates the read-only class mapped over an entity's view
+func generateViewModelContent(view *formatdef.View, relationships []viewRelationship, config SQLAlchemyConfig, r *registry.Registry) []byte {
+	className := getViewClassName(view.EntityName)
+	tableVariable := view.Name
+
+	cb := formatdef.NewContentBuilder("    ")
+	cb.Line("# Read-only SQLAlchemy mapping of the %s database view", view.Name)
+	cb.Line("")
+
+	imports := NewImportTracker(r)
+	imports.AddFrom(".base", "Base")
+	imports.AddSQLAlchemy("Column", "MetaData", "Table")
+	for _, column := range view.Columns {
+		switch {
+		case column.EnumName != "":
+			imports.AddSQLAlchemy("Enum")
+			imports.TrackFieldType(column.EnumName)
+		case column.Structure != "":
+			imports.AddFrom(".base", "StructureJSON")
+			imports.TrackFieldType(column.Structure)
+		default:
+			imports.AddSQLAlchemy(column.Type)
+		}
+	}
+	if len(relationships) > 0 {
+		imports.AddFrom("sqlalchemy.orm", "relationship")
+	}
+	imports.Generate(cb)
+	cb.Line("")
+
+	cb.Line("# The view stays out of Base.metadata, so create_all() and autogenerate don't create it as a table")
+	cb.Line("%s = Table(", tableVariable)
+	cb.Indent()
+	cb.Line("%s,", quotePythonString(view.Name))
+	cb.Line("MetaData(),")
+	for _, column := range view.Columns {
+		rendered := renderColumn(column.Column, &formatdef.Table{}, config)
+		if column.Attribute != column.Name {
+			// Mapped attributes of a Table are named after the column keys
+			rendered = strings.TrimSuffix(rendered, ")") + ", key=" + quotePythonString(column.Attribute) + ")"
+		}
+		cb.Line("%s,", rendered)
+	}
+	if view.Schema != "" {
+		cb.Line("schema=%s,", quotePythonString(view.Schema))
+	}
+	cb.Line("info={'is_view': True},")
+	cb.Dedent()
+	cb.Line(")")
+	cb.Line("")
+	cb.Line("")
+
+	cb.Line("class %s(Base):", className)
+	cb.Indent()
+	cb.Line(`"""Read-only %s entity served by the %s view."""`, view.EntityName, view.Name)
+	cb.Line("__table__ = %s", tableVariable)
+	if len(relationships) > 0 {
+		cb.Line("")
+	}
+	for _, relationship := range relationships {
+		args := []string{fmt.Sprintf("%q", relationship.Model), "primaryjoin=" + quotePythonString(relationship.PrimaryJoin)}
+		if !relationship.UseList {
+			args = append(args, "uselist=False")
+		}
+		args = append(args, "viewonly=True")
+		cb.Line("%s = relationship(%s)", relationship.Attribute, strings.Join(args, ", "))
+	}
+	cb.CustomRegion()
+	cb.Dedent()
+	return cb.Build()
+}
@@ -0,0 +1,185 @@
+package compile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile/cfg"
+)
+
+// pythonViewPrelude loads the generated SQLite schema into an in-memory database and stubs modules
+// with recorders of their calls, so the view SQL runs for real and the view mapping can be inspected
+const pythonViewPrelude = `
+import glob
+import importlib.util
+import os
+import sqlite3
+import sys
+import types
+
+
+class Recorder:
+    def __init__(self, name, args=(), kwargs=None):
+        self.name = name
+        self.args = args
+        self.kwargs = kwargs or {}
+
+    def __call__(self, *args, **kwargs):
+        return Recorder(self.name, args, kwargs)
+
+    def __getattr__(self, name):
+        if name.startswith('__'):
+            raise AttributeError(name)
+        return Recorder(f'{self.name}.{name}')
+
+
+def stub_module(name, **attributes):
+    module = types.ModuleType(name)
+    module.__getattr__ = lambda attribute: Recorder(attribute)
+    module.__dict__.update(attributes)
+    sys.modules[name] = module
+    return module
+
+
+def create_database():
+    database = sqlite3.connect(':memory:')
+    with open(os.path.join('working', 'schema.sql')) as schema:
+        database.executescript(schema.read())
+    database.execute("INSERT INTO company (id, name, tax_id) VALUES (1, 'Acme', 'DE123')")
+    for id_, last_name in ((1, 'Lovelace'), (2, 'Hopper')):
+        database.execute(
+            'INSERT INTO person (id, first_name, last_name, nationality, company_id, home_address_city, '
+            'home_address_house_nr, home_address_street, home_address_zip_code) '
+            "VALUES (?, 'Ada', ?, 'US', 1, 'Berlin', '1', 'Main', '10115')",
+            (id_, last_name),
+        )
+    database.execute("INSERT INTO contact_info (id, email, person_id) VALUES (1, 'ada@example.com', 1)")
+    return database
+
+
+def select_view(database):
+    return database.execute('SELECT email, id, last_name, nationality, company_id FROM person_view ORDER BY id').fetchall()
+
+
+# People without contact info are kept by the outer join
+expected_rows = [('ada@example.com', 1, 'Lovelace', 'US', 1), (None, 2, 'Hopper', 'US', 1)]
+`
+
+type CompileViewsTestSuite struct {
+	generatedCodeFixture
+}
+
+func TestCompileViewsTestSuite(t *testing.T) {
+	suite.Run(t, new(CompileViewsTestSuite))
+}
+
+func (suite *CompileViewsTestSuite) SetupTest() {
+	suite.setupFixture("minimal", pythonViewPrelude)
+}
+
+func (suite *CompileViewsTestSuite) TearDownTest() {
+	suite.tearDownFixture()
+}
+
+// compileViews compiles the minimal registry with Person served by a view and SQLite DDL
+func (suite *CompileViewsTestSuite) compileViews(migrations cfg.MigrationConfig) {
+	config := compile.MorpheCompileConfig{
+		DDL:        cfg.DDLConfig{Enabled: true, Dialect: "sqlite"},
+		Migrations: migrations,
+	}
+	config.MorpheConfig.Entities = cfg.EntityConfig{Views: []string{"Person"}}
+	suite.compileConfig(config)
+}
+
+func (suite *CompileViewsTestSuite) TestView_DDL() {
+	suite.compileViews(cfg.MigrationConfig{})
+
+	suite.Contains(suite.readOutput("schema.sql"), "CREATE VIEW person_view AS\nSELECT\n")
+
+	suite.runPython(`
+database = create_database()
+assert select_view(database) == expected_rows, select_view(database)
+`)
+}
+
+func (suite *CompileViewsTestSuite) TestView_Mapping() {
+	suite.compileViews(cfg.MigrationConfig{})
+
+	suite.Contains(suite.readOutput("models/__init__.py"), "from .person_view import PersonView")
+
+	suite.runPython(`
+stub_module('sqlalchemy')
+stub_module('sqlalchemy.orm')
+models = types.ModuleType('working.models')
+models.__path__ = [os.path.join('working', 'models')]
+sys.modules['working.models'] = models
+stub_module('working.models.base', Base=object)
+
+from working.models.person_view import PersonView, person_view
+
+assert PersonView.__table__ is person_view
+assert person_view.name == 'Table' and person_view.args[0] == 'person_view'
+assert person_view.kwargs == {'info': {'is_view': True}}, person_view.kwargs
+
+# The columns are keyed like the entity attributes and keep the view's column names
+columns = person_view.args[2:]
+assert [column.args[0] for column in columns] == ['email', 'id', 'last_name', 'nationality', 'company_id']
+keys = [column.kwargs.get('key', column.args[0]) for column in columns]
+assert keys == ['email', 'id_', 'last_name', 'nationality', 'company_id'], keys
+assert [column.args[0] for column in columns if column.kwargs.get('primary_key')] == ['id']
+assert columns[0].kwargs['nullable'] is True
+
+# Relationships can be read through but never write back to the view
+company = PersonView.company
+assert company.name == 'relationship' and company.args == ('Company',)
+assert company.kwargs == {'primaryjoin': 'foreign(PersonView.company_id) == Company.id_', 'uselist': False, 'viewonly': True}, company.kwargs
+
+# The rows of the view match the columns of the mapping
+database = create_database()
+cursor = database.execute('SELECT * FROM person_view')
+assert [description[0] for description in cursor.description] == [column.args[0] for column in columns]
+`)
+}
+
+func (suite *CompileViewsTestSuite) TestView_Migration() {
+	suite.compileViews(cfg.MigrationConfig{Enabled: true})
+
+	suite.runPython(`
+class FakeOp:
+    def __init__(self):
+        self.executed = []
+
+    def execute(self, sql):
+        self.executed.append(sql)
+
+    def __getattr__(self, name):
+        return Recorder(name)
+
+
+op = FakeOp()
+stub_module('alembic', op=op)
+stub_module('sqlalchemy')
+stub_module('sqlalchemy.dialects', postgresql=Recorder('postgresql'))
+
+migration_paths = glob.glob(os.path.join('working', 'migrations', '*.py'))
+assert len(migration_paths) == 1, migration_paths
+spec = importlib.util.spec_from_file_location('migration', migration_paths[0])
+migration = importlib.util.module_from_spec(spec)
+spec.loader.exec_module(migration)
+
+migration.upgrade()
+create_view, op.executed = op.executed, []
+migration.downgrade()
+drop_view = op.executed
+assert len(create_view) == 1 and len(drop_view) == 1
+
+# The downgrade drops the view schema.sql created, and the upgrade creates it again
+database = create_database()
+database.execute(drop_view[0])
+assert database.execute("SELECT name FROM sqlite_master WHERE type = 'view'").fetchall() == []
+database.execute(create_view[0])
+assert select_view(database) == expected_rows, select_view(database)
+`)
+}
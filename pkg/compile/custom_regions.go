@@ -0,0 +1,112 @@
+package compile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+// classLinePattern matches the class statements custom regions are keyed by
+var classLinePattern = regexp.MustCompile(`^\s*class\s+(\w+)`)
+
+// customRegion is the hand-written code of a region, keyed by the class it is in and its position within it
+type customRegion struct {
+	Key   string
+	Lines []string
+}
+
+// forEachCustomRegion calls visit with the key and line range of the code inside each custom region.
+// Regions are keyed by the innermost class they are in and their position within it, so they survive
+// the classes of a module being reordered.
+func forEachCustomRegion(lines []string, visit func(key string, begin int, end int)) {
+	var className string
+	counts := make(map[string]int)
+	for i := 0; i < len(lines); i++ {
+		if match := classLinePattern.FindStringSubmatch(lines[i]); match != nil {
+			className = match[1]
+			continue
+		}
+		// Anything at column 0 ends the class, so module level regions aren't keyed by the class above them
+		if lines[i] != "" && !strings.HasPrefix(lines[i], " ") && !strings.HasPrefix(lines[i], "\t") {
+			className = ""
+		}
+		if strings.TrimSpace(lines[i]) != formatdef.CustomRegionBegin {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && strings.TrimSpace(lines[end]) != formatdef.CustomRegionEnd {
+			end++
+		}
+		// Unterminated regions aren't regions
+		if end == len(lines) {
+			return
+		}
+		key := fmt.Sprintf("%s#%d", className, counts[className])
+		counts[className]++
+		visit(key, i+1, end)
+		i = end
+	}
+}
+
+// extractCustomRegions returns the code inside the custom regions of a file that isn't blank
+func extractCustomRegions(content string) []customRegion {
+	lines := strings.Split(content, "\n")
+	var regions []customRegion
+	forEachCustomRegion(lines, func(key string, begin int, end int) {
+		if strings.TrimSpace(strings.Join(lines[begin:end], "")) == "" {
+			return
+		}
+		regions = append(regions, customRegion{Key: key, Lines: append([]string(nil), lines[begin:end]...)})
+	})
+	return regions
+}
+
+// fillCustomRegions carries the code of custom regions into the matching regions of regenerated content,
+// returning the keys of the regions the content has no region for
+func fillCustomRegions(content string, regions []customRegion) (string, []string) {
+	if len(regions) == 0 {
+		return content, nil
+	}
+	byKey := make(map[string][]string)
+	for _, region := range regions {
+		byKey[region.Key] = region.Lines
+	}
+
+	lines := strings.Split(content, "\n")
+	var filled []string
+	last := 0
+	forEachCustomRegion(lines, func(key string, begin int, end int) {
+		regionLines, ok := byKey[key]
+		if !ok {
+			return
+		}
+		filled = append(filled, lines[last:begin]...)
+		filled = append(filled, regionLines...)
+		last = end
+		delete(byKey, key)
+	})
+	filled = append(filled, lines[last:]...)
+
+	var dropped []string
+	for _, region := range regions {
+		if _, ok := byKey[region.Key]; ok {
+			dropped = append(dropped, region.Key)
+		}
+	}
+	return strings.Join(filled, "\n"), dropped
+}
+
+// stripCustomRegions removes the code inside custom regions, so hashes only cover generated code
+func stripCustomRegions(content string) string {
+	lines := strings.Split(content, "\n")
+	var stripped []string
+	last := 0
+	forEachCustomRegion(lines, func(key string, begin int, end int) {
+		stripped = append(stripped, lines[last:begin]...)
+		last = end
+	})
+	stripped = append(stripped, lines[last:]...)
+	return strings.Join(stripped, "\n")
+}
@@ -1,6 +1,7 @@
 package compile
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -10,22 +11,50 @@ import (
 
 // ImportTracker tracks required imports for Python code generation
 type ImportTracker struct {
-	sqlalchemy  []string
-	typing      []string
-	datetime    bool
-	enums       map[string]bool
-	models      map[string]bool
-	registry    *registry.Registry
-	fromImports map[string][]string // module -> list of imports
+	sqlalchemy []string
+	typing     []string
+	datetime   bool
+	enums      map[string]bool
+	models     map[string]bool
+	structures map[string]bool
+	// Types defined by the generated module itself, which are never imported
+	excluded map[string]bool
+	// Extra import statements only needed for type checking
+	typeCheckingImports []string
+	// Package structures are imported from, relative to the generated module
+	structuresPackage string
+	registry          *registry.Registry
+	fromImports       map[string][]string // module -> list of imports
 }
 
 // NewImportTracker creates a new import tracker
 func NewImportTracker(r *registry.Registry) *ImportTracker {
 	return &ImportTracker{
-		enums:       make(map[string]bool),
-		models:      make(map[string]bool),
-		registry:    r,
-		fromImports: make(map[string][]string),
+		enums:             make(map[string]bool),
+		models:            make(map[string]bool),
+		structures:        make(map[string]bool),
+		excluded:          make(map[string]bool),
+		structuresPackage: "..structures",
+		registry:          r,
+		fromImports:       make(map[string][]string),
+	}
+}
+
+// SetStructuresPackage sets the package structures are imported from, e.g. "." within the structures package
+func (it *ImportTracker) SetStructuresPackage(pkg string) {
+	it.structuresPackage = pkg
+}
+
+// ExcludeType keeps a type defined by the generated module from being imported into it
+func (it *ImportTracker) ExcludeType(typeName string) {
+	it.excluded[typeName] = true
+}
+
+// AddTypeCheckingImport adds an import statement to the TYPE_CHECKING block
+func (it *ImportTracker) AddTypeCheckingImport(statement string) {
+	it.AddTyping("TYPE_CHECKING")
+	if !containsString(it.typeCheckingImports, statement) {
+		it.typeCheckingImports = append(it.typeCheckingImports, statement)
 	}
 }
 
@@ -80,13 +109,15 @@ func (it *ImportTracker) TrackFieldType(typeName string) {
 	// Extract inner types and check if they're enums or models
 	innerTypes := extractAllInnerTypes(typeName)
 	for _, innerType := range innerTypes {
-		if innerType != "" && !isBasicType(innerType) {
+		if innerType != "" && !isBasicType(innerType) && !it.excluded[innerType] {
 			switch resolveFieldType(innerType, it.registry) {
 			case "enum":
 				it.enums[innerType] = true
 			case "model":
 				it.models[innerType] = true
 				it.AddTyping("TYPE_CHECKING")
+			case "structure":
+				it.structures[innerType] = true
 			}
 		}
 	}
@@ -147,19 +178,37 @@ func (it *ImportTracker) Generate(cb *formatdef.ContentBuilder) {
 		}
 	}
 
+	// Structures
+	if len(it.structures) > 0 {
+		var structureNames []string
+		for structure := range it.structures {
+			structureNames = append(structureNames, structure)
+		}
+		sort.Strings(structureNames)
+		for _, structureName := range structureNames {
+			cb.Line("from %s import %s", getStructureModule(it.structuresPackage, structureName), structureName)
+		}
+	}
+
 	cb.Line("")
 
 	// Models under TYPE_CHECKING
-	if len(it.models) > 0 {
+	if len(it.models) > 0 || len(it.typeCheckingImports) > 0 {
 		cb.Line("if TYPE_CHECKING:")
 		cb.Indent()
+		for _, statement := range it.typeCheckingImports {
+			cb.Line("%s", statement)
+		}
 		var modelNames []string
 		for model := range it.models {
 			modelNames = append(modelNames, model)
 		}
 		sort.Strings(modelNames)
 		for _, modelName := range modelNames {
-			cb.Line("from .%s import %s", formatdef.ToSnakeCase(modelName), modelName)
+			statement := fmt.Sprintf("from .%s import %s", formatdef.ToSnakeCase(modelName), modelName)
+			if !containsString(it.typeCheckingImports, statement) {
+				cb.Line("%s", statement)
+			}
 		}
 		cb.Dedent()
 	}
@@ -167,6 +216,15 @@ func (it *ImportTracker) Generate(cb *formatdef.ContentBuilder) {
 
 // Helper functions
 
+// getStructureModule returns the module path of a structure within a package
+func getStructureModule(pkg string, structureName string) string {
+	module := formatdef.ToSnakeCase(structureName)
+	if strings.HasSuffix(pkg, ".") {
+		return pkg + module
+	}
+	return pkg + "." + module
+}
+
 func containsString(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
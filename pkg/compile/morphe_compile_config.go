@@ -1,6 +1,7 @@
 package compile
 
 import (
+	"fmt"
 	"path"
 
 	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
@@ -20,19 +21,77 @@ type MorpheCompileConfig struct {
 
 	// Type-specific configuration
 	MorpheConfig cfg.MorpheConfig
+
+	// Migration generation configuration
+	Migrations cfg.MigrationConfig
+
+	// SQL DDL output configuration
+	DDL cfg.DDLConfig
+
+	// Compare the generated output with the files under OutputPath instead of writing it
+	Check bool
+
+	// Overwrite generated files that were edited outside their custom regions
+	Force bool
 }
 
+// Table naming strategies
+const (
+	TableNamingSnake       = "snake"
+	TableNamingSnakePlural = "snake_plural"
+	TableNamingPreserve    = "preserve"
+)
+
+// Column naming strategies
+const (
+	ColumnNamingSnakeCase = "snake_case"
+	ColumnNamingPreserve  = "preserve"
+)
+
+// Storage of structure-typed model fields
+const (
+	StructureStorageComposite = "composite"
+	StructureStorageJSON      = "json"
+	StructureStorageJSONB     = "jsonb"
+)
+
 // SQLAlchemyConfig contains SQLAlchemy-specific configuration options
 type SQLAlchemyConfig struct {
 	// SQLAlchemy-specific options
-	UseDeclarative  bool   `json:"useDeclarative"`  // Use declarative base (default: true)
-	UseDataclass    bool   `json:"useDataclass"`    // Use dataclass mixin (default: false)
-	AddTypeHints    bool   `json:"addTypeHints"`    // Add type hints (default: true)
-	GenerateInit    bool   `json:"generateInit"`    // Generate __init__.py files (default: true)
-	IndentSize      int    `json:"indentSize"`      // Number of spaces for indent (default: 4)
-	PythonVersion   string `json:"pythonVersion"`   // Target Python version (default: "3.8")
+	UseDeclarative bool   `json:"useDeclarative"` // Use declarative base (default: true)
+	UseDataclass   bool   `json:"useDataclass"`   // Use dataclass mixin (default: false)
+	AddTypeHints   bool   `json:"addTypeHints"`   // Add type hints (default: true)
+	GenerateInit   bool   `json:"generateInit"`   // Generate __init__.py files (default: true)
+	IndentSize     int    `json:"indentSize"`     // Number of spaces for indent (default: 4)
+	PythonVersion  string `json:"pythonVersion"`  // Target Python version (default: "3.8")
+	// Write each type category to one module (enums.py, models.py, ...) instead of a package (default: false)
+	SingleFile      bool   `json:"singleFile,omitempty"`
 	TableNamePrefix string `json:"tableNamePrefix"` // Prefix for table names (default: "")
 	TableNameSuffix string `json:"tableNameSuffix"` // Suffix for table names (default: "")
+	TableNaming     string `json:"tableNaming"`     // Table naming strategy: "snake", "snake_plural", "preserve" (default: "snake")
+
+	// Column naming strategy: "snake_case" or "preserve" (default: "snake_case")
+	ColumnNaming string `json:"columnNaming,omitempty"`
+	// Explicit column names keyed by model and field name, taking precedence over the strategy
+	ColumnNames map[string]map[string]string `json:"columnNames,omitempty"`
+
+	// Storage of structure-typed model fields: "composite", "json" or "jsonb" (default: "composite")
+	StructureStorage string `json:"structureStorage,omitempty"`
+	// Structure storage keyed by model and field name, taking precedence over StructureStorage
+	StructureStorageFields map[string]map[string]string `json:"structureStorageFields,omitempty"`
+	// Track in-place changes of structure values with MutableComposite / Mutable (default: false)
+	MutableStructures bool `json:"mutableStructures,omitempty"`
+
+	// Plural forms used for table names and to-many relation names, keyed by singular snake_case word
+	Irregulars map[string]string `json:"irregulars,omitempty"`
+
+	// Database schema for all tables without a per-model schema (default: "")
+	DefaultSchema string `json:"defaultSchema,omitempty"`
+	// Per-model table overrides keyed by model name
+	Tables map[string]cfg.TableConfig `json:"tables,omitempty"`
+
+	// Constraint naming convention by kind ("ix", "uq", "ck", "fk", "pk"), merged over DefaultNamingConvention
+	NamingConvention map[string]string `json:"namingConvention,omitempty"`
 }
 
 // DefaultMorpheCompileConfig creates a default configuration
@@ -57,6 +116,7 @@ func DefaultMorpheCompileConfig(
 			PythonVersion:   "3.8",
 			TableNamePrefix: "",
 			TableNameSuffix: "",
+			TableNaming:     TableNamingSnake,
 		},
 	}
 }
@@ -68,6 +128,54 @@ func (config MorpheCompileConfig) Validate() error {
 		return err
 	}
 
+	if err := config.MorpheConfig.Validate(); err != nil {
+		return err
+	}
+
+	if err := config.Migrations.Validate(); err != nil {
+		return err
+	}
+
+	if err := config.DDL.Validate(); err != nil {
+		return err
+	}
+
+	switch config.FormatConfig.TableNaming {
+	case "", TableNamingSnake, TableNamingSnakePlural, TableNamingPreserve:
+	default:
+		return fmt.Errorf("invalid table naming strategy: %s (must be 'snake', 'snake_plural', or 'preserve')", config.FormatConfig.TableNaming)
+	}
+
+	switch config.FormatConfig.ColumnNaming {
+	case "", ColumnNamingSnakeCase, ColumnNamingPreserve:
+	default:
+		return fmt.Errorf("invalid column naming strategy: %s (must be 'snake_case' or 'preserve')", config.FormatConfig.ColumnNaming)
+	}
+
+	if err := validateStructureStorage(config.FormatConfig.StructureStorage); err != nil {
+		return err
+	}
+	for _, fields := range config.FormatConfig.StructureStorageFields {
+		for _, storage := range fields {
+			if err := validateStructureStorage(storage); err != nil {
+				return err
+			}
+		}
+	}
+
+	if config.MorpheConfig.Structures.Frozen {
+		if !config.MorpheConfig.Structures.UseDataclass && !config.FormatConfig.UseDataclass {
+			return fmt.Errorf("frozen structures require useDataclass")
+		}
+		if config.FormatConfig.MutableStructures {
+			return fmt.Errorf("frozen structures can't be combined with mutableStructures")
+		}
+	}
+
+	if err := validateNamingConvention(config.FormatConfig.NamingConvention); err != nil {
+		return err
+	}
+
 	// TODO: Add format-specific validation
 	// Examples:
 	// - Check if package prefix is valid
@@ -76,3 +184,12 @@ func (config MorpheCompileConfig) Validate() error {
 
 	return nil
 }
+
+// validateStructureStorage checks a structure storage option
+func validateStructureStorage(storage string) error {
+	switch storage {
+	case "", StructureStorageComposite, StructureStorageJSON, StructureStorageJSONB:
+		return nil
+	}
+	return fmt.Errorf("invalid structure storage: %s (must be 'composite', 'json', or 'jsonb')", storage)
+}
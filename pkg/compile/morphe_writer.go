@@ -1,7 +1,10 @@
 package compile
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -17,6 +20,21 @@ type MorpheWriter struct {
 	CreateIndexFile    bool // Default: true (create index that imports all)
 	IndentSize         int  // Default: 2 or 4 depending on format
 	AddGeneratedHeader bool // Default: true
+	// Overwrite generated files that were edited outside their custom regions (default: false)
+	Force bool
+
+	// Contents written in single file mode by type, kept to add modules written later
+	singleFileContents map[string]map[string][]byte
+	// Files staged by path until they are committed to the output path
+	files map[string][]byte
+	// In-memory writers are only compared with the output path and never committed
+	inMemory bool
+	// Registry files each type is defined in, recorded as the sources of the generated files
+	sources registrySources
+	// Content hashes of the files generated by this run, by path
+	generated map[string]string
+	// Files of the previous run to delete on commit
+	pruned []string
 }
 
 // NewMorpheWriter creates a new MorpheWriter instance with sensible defaults
@@ -28,37 +46,104 @@ func NewMorpheWriter(outputPath string) *MorpheWriter {
 		CreateIndexFile:    true,
 		IndentSize:         4,
 		AddGeneratedHeader: true,
+		files:              make(map[string][]byte),
 	}
 }
 
+// NewMemoryMorpheWriter creates a MorpheWriter that keeps the files in memory instead of writing them
+func NewMemoryMorpheWriter(outputPath string) *MorpheWriter {
+	w := NewMorpheWriter(outputPath)
+	w.inMemory = true
+	return w
+}
+
+// Files returns the files staged by the writer, keyed by path
+func (w *MorpheWriter) Files() map[string][]byte {
+	return w.files
+}
+
+// generatedHeaderLines start the header of generated files, followed by the hash of the generated code
+const (
+	generatedHeaderLines      = "# Code generated by Morphe\n# Source: Morphe Registry\n"
+	generatedHeaderHashPrefix = "# Hash: "
+)
+
 // getGeneratedHeader returns a header comment for generated files
-func (w *MorpheWriter) getGeneratedHeader() string {
-	return `# Code generated by Morphe
-# Source: Morphe Registry
+func (w *MorpheWriter) getGeneratedHeader(hash string) string {
+	return generatedHeaderLines + generatedHeaderHashPrefix + hash + "\n\n"
+}
 
-`
+// splitGeneratedHeader splits a generated file into the hash in its header and its body.
+// Files without a hash are returned whole as the body.
+func splitGeneratedHeader(content string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(content, generatedHeaderLines)
+	if !ok {
+		return "", content, false
+	}
+	hashLine, body, ok := strings.Cut(rest, "\n\n")
+	hash, hasHash := strings.CutPrefix(hashLine, generatedHeaderHashPrefix)
+	if !ok || !hasHash {
+		return "", content, false
+	}
+	return hash, body, true
 }
 
-// ensureDir creates a directory if it doesn't exist
-func (w *MorpheWriter) ensureDir(dir string) error {
-	return os.MkdirAll(dir, 0755)
+// ensureDir creates a directory if it doesn't exist, returning the topmost directory it created or ""
+func (w *MorpheWriter) ensureDir(dir string) (string, error) {
+	created := ""
+	for missing := filepath.Clean(dir); ; missing = filepath.Dir(missing) {
+		if _, err := os.Stat(missing); err == nil || filepath.Dir(missing) == missing {
+			break
+		}
+		created = missing
+	}
+	return created, os.MkdirAll(dir, 0755)
 }
 
-// writeFile writes content to a file with optional header
+// writeFile writes content to a file with optional header.
+// The code in the custom regions of the existing file is carried over, and files edited outside
+// their custom regions since they were generated aren't overwritten unless forced.
 func (w *MorpheWriter) writeFile(path string, content []byte) error {
-	// Add generated header if enabled
-	if w.AddGeneratedHeader {
-		header := []byte(w.getGeneratedHeader())
-		content = append(header, content...)
+	if !w.AddGeneratedHeader {
+		return w.writeRawFile(path, content)
 	}
 
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if err := w.ensureDir(dir); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	body := string(content)
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err == nil {
+		// In-memory writers don't overwrite anything, the drift is reported instead
+		refuse := !w.Force && !w.inMemory
+		hash, existingBody, hasHash := splitGeneratedHeader(string(existing))
+		if refuse && hasHash && hashContent([]byte(stripCustomRegions(existingBody))) != hash {
+			return ErrModifiedOutputFile(path)
+		}
+		var dropped []string
+		body, dropped = fillCustomRegions(body, extractCustomRegions(existingBody))
+		if refuse && len(dropped) > 0 {
+			return ErrCustomRegionDropped(path, dropped[0])
+		}
 	}
 
-	return os.WriteFile(path, content, 0644)
+	header := w.getGeneratedHeader(hashContent([]byte(stripCustomRegions(body))))
+	return w.writeRawFile(path, []byte(header+body))
+}
+
+// writeRawFile writes content to a file as-is, without the generated header
+func (w *MorpheWriter) writeRawFile(path string, content []byte) error {
+	if w.generated == nil {
+		w.generated = make(map[string]string)
+	}
+	w.generated[path] = hashContent(content)
+	return w.storeFile(path, content)
+}
+
+// storeFile stages a file until the writer is committed
+func (w *MorpheWriter) storeFile(path string, content []byte) error {
+	w.files[path] = content
+	return nil
 }
 
 // WriteEnum writes a single enum definition to a file
@@ -89,6 +174,27 @@ func (w *MorpheWriter) WriteEntity(entityName string, content []byte) error {
 	return w.writeFile(filePath, content)
 }
 
+// WriteEntityErrors writes the errors module shared by the entities
+func (w *MorpheWriter) WriteEntityErrors(content []byte) error {
+	if !w.UseMultiFile {
+		// The errors are merged into the entities module
+		entityContents := map[string][]byte{"errors": content}
+		for name, entityContent := range w.singleFileContents["entities"] {
+			entityContents[name] = entityContent
+		}
+		return w.writeSingleFile("entities", entityContents)
+	}
+	filePath := filepath.Join(w.OutputPath, "entities", "errors"+w.FileExtension)
+	return w.writeFile(filePath, content)
+}
+
+// WriteRepository writes a single entity repository to a file
+func (w *MorpheWriter) WriteRepository(entityName string, content []byte) error {
+	fileName := toFileName(entityName) + w.FileExtension
+	filePath := filepath.Join(w.OutputPath, "repositories", fileName)
+	return w.writeFile(filePath, content)
+}
+
 // WriteAllEnums writes multiple enum definitions
 func (w *MorpheWriter) WriteAllEnums(enumContents map[string][]byte) error {
 	if w.UseMultiFile {
@@ -164,6 +270,24 @@ func (w *MorpheWriter) WriteAllEntities(entityContents map[string][]byte) error
 	return w.writeSingleFile("entities", entityContents)
 }
 
+// WriteAllRepositories writes the repositories of multiple entities
+func (w *MorpheWriter) WriteAllRepositories(repositoryContents map[string][]byte) error {
+	if w.UseMultiFile {
+		for entityName, content := range repositoryContents {
+			if err := w.WriteRepository(entityName, content); err != nil {
+				return err
+			}
+		}
+
+		if w.CreateIndexFile {
+			return w.writeRepositoryIndex(repositoryContents)
+		}
+		return nil
+	}
+
+	return w.writeSingleFile("repositories", repositoryContents)
+}
+
 // Index file generators - creates a file that imports/exports all types
 func (w *MorpheWriter) writeEnumIndex(contents map[string][]byte) error {
 	// Python __init__.py file
@@ -226,46 +350,275 @@ func (w *MorpheWriter) writeEntityIndex(contents map[string][]byte) error {
 	return w.writeFile(filePath, content)
 }
 
-// writeSingleFile writes all content of a type to a single file
-func (w *MorpheWriter) writeSingleFile(typeName string, contents map[string][]byte) error {
-	var combined []byte
-
-	// Add file header
-	if w.AddGeneratedHeader {
-		combined = append(combined, []byte(w.getGeneratedHeader())...)
+func (w *MorpheWriter) writeRepositoryIndex(contents map[string][]byte) error {
+	var imports []string
+	for entityName := range contents {
+		fileName := toFileName(entityName)
+		imports = append(imports, fmt.Sprintf("from .%s import %sRepository", fileName, entityName))
 	}
 
-	// Combine all contents
-	for name, content := range contents {
-		combined = append(combined, []byte(fmt.Sprintf("\n// --- %s ---\n", name))...)
-		combined = append(combined, content...)
-		combined = append(combined, '\n')
+	sort.Strings(imports)
+	content := []byte(strings.Join(imports, "\n"))
+	content = append(content, '\n')
+
+	filePath := filepath.Join(w.OutputPath, "repositories", "__init__.py")
+	return w.writeFile(filePath, content)
+}
+
+// writeSingleFile merges all content of a type into a single Python module next to base.py
+func (w *MorpheWriter) writeSingleFile(typeName string, contents map[string][]byte) error {
+	if w.singleFileContents == nil {
+		w.singleFileContents = make(map[string]map[string][]byte)
 	}
+	w.singleFileContents[typeName] = contents
 
-	// Write to single file
 	fileName := typeName + w.FileExtension
-	filePath := filepath.Join(w.OutputPath, fileName)
-	return os.WriteFile(filePath, combined, 0644)
+	return w.writeFile(filepath.Join(w.OutputPath, fileName), mergePythonModules(typeName, contents))
 }
 
 // WriteBaseFile writes the base.py file that defines the declarative base
-func (w *MorpheWriter) WriteBaseFile() error {
-	content := []byte(`# Code generated by Morphe
-# SQLAlchemy Base definition
+func (w *MorpheWriter) WriteBaseFile(content []byte) error {
+	filePath := filepath.Join(w.OutputPath, "base.py")
+	return w.writeFile(filePath, content)
+}
 
-from sqlalchemy.ext.declarative import declarative_base
+// WriteMigration writes an Alembic revision to the migrations directory
+func (w *MorpheWriter) WriteMigration(fileName string, content []byte) error {
+	filePath := filepath.Join(w.OutputPath, "migrations", fileName+w.FileExtension)
+	return w.writeFile(filePath, content)
+}
 
-# Create the declarative base that all models will inherit from
-Base = declarative_base()
+// WriteSchemaSnapshot writes the exported schema JSON used for future migration diffs
+func (w *MorpheWriter) WriteSchemaSnapshot(content []byte) error {
+	filePath := filepath.Join(w.OutputPath, "schema.json")
+	return w.writeRawFile(filePath, content)
+}
 
-# You can customize the Base class here if needed
-# For example:
-# Base.query = db.session.query_property()
-`)
+// WriteSQLSchema writes the rendered SQL DDL to schema.sql
+func (w *MorpheWriter) WriteSQLSchema(content []byte) error {
+	filePath := filepath.Join(w.OutputPath, "schema.sql")
+	return w.writeRawFile(filePath, content)
+}
 
-	filePath := filepath.Join(w.OutputPath, "base.py")
-	// Don't add the header since content already has it
-	return os.WriteFile(filePath, content, 0644)
+// WriteManifest writes the manifest of this run and marks the files listed in the manifest of the previous run
+// that are no longer generated to be pruned on commit. Files changed since they were generated are left alone
+// with a warning. Returns the paths of the files to prune.
+func (w *MorpheWriter) WriteManifest() ([]string, error) {
+	previous, err := readManifest(w.OutputPath)
+	if err != nil {
+		fmt.Printf("Warning: %v, skipping pruning\n", err)
+	}
+
+	manifest := outputManifest{Files: []manifestFile{}}
+	for path, hash := range w.generated {
+		relativePath, err := filepath.Rel(w.OutputPath, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record %s in the manifest: %w", path, err)
+		}
+		manifest.Files = append(manifest.Files, manifestFile{
+			Path:    filepath.ToSlash(relativePath),
+			Hash:    hash,
+			Sources: w.getManifestSources(relativePath),
+		})
+	}
+
+	var pruned []string
+	if previous != nil {
+		for _, file := range previous.Files {
+			path := filepath.Join(w.OutputPath, filepath.FromSlash(file.Path))
+			if _, isGenerated := w.generated[path]; isGenerated || !isInsideOutput(file.Path) {
+				continue
+			}
+			content, err := os.ReadFile(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			// Migrations accumulate across runs, so earlier revisions stay listed
+			if isAppendOnlyOutput(file.Path) {
+				manifest.Files = append(manifest.Files, file)
+				continue
+			}
+			if hashContent(content) != file.Hash {
+				fmt.Printf("Warning: %s is no longer generated but was modified, leaving it in place\n", file.Path)
+				continue
+			}
+			pruned = append(pruned, path)
+		}
+	}
+	sort.Strings(pruned)
+	w.pruned = pruned
+
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the manifest: %w", err)
+	}
+	return pruned, w.storeFile(filepath.Join(w.OutputPath, ManifestFileName), append(data, '\n'))
+}
+
+// Commit writes the staged files to the output path and deletes the pruned ones.
+// Every file is written to a temporary file next to it first, and only once all of them are written
+// are they renamed over the files in the output path. The replaced and pruned files are moved aside
+// until every rename succeeded and restored otherwise, so a failed commit leaves the output untouched.
+func (w *MorpheWriter) Commit() error {
+	if w.inMemory {
+		return fmt.Errorf("in-memory writers can't be committed")
+	}
+
+	var paths []string
+	for path := range w.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var createdDirs, tempPaths []string
+	rollback := func() {
+		for _, tempPath := range tempPaths {
+			os.Remove(tempPath)
+		}
+		for i := len(createdDirs) - 1; i >= 0; i-- {
+			os.RemoveAll(createdDirs[i])
+		}
+	}
+
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		created, err := w.ensureDir(dir)
+		if created != "" {
+			createdDirs = append(createdDirs, created)
+		}
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+		tempPath, err := writeTempFile(path, w.files[path])
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		tempPaths = append(tempPaths, tempPath)
+	}
+
+	// Files moved aside by path, with "" for the files that didn't exist yet
+	var moved []string
+	backups := make(map[string]string)
+	restore := func() {
+		for i := len(moved) - 1; i >= 0; i-- {
+			path := moved[i]
+			if backups[path] == "" {
+				os.Remove(path)
+			} else {
+				os.Rename(backups[path], path)
+			}
+		}
+	}
+
+	for i, path := range paths {
+		backupPath, err := backupFile(path)
+		if err == nil {
+			moved = append(moved, path)
+			backups[path] = backupPath
+			err = os.Rename(tempPaths[i], path)
+		}
+		if err != nil {
+			restore()
+			tempPaths = tempPaths[i:]
+			rollback()
+			return fmt.Errorf("failed to commit %s: %w", path, err)
+		}
+	}
+	tempPaths = nil
+
+	for _, path := range w.pruned {
+		backupPath, err := backupFile(path)
+		if err != nil {
+			restore()
+			rollback()
+			return fmt.Errorf("failed to prune %s: %w", path, err)
+		}
+		if backupPath != "" {
+			moved = append(moved, path)
+			backups[path] = backupPath
+		}
+	}
+
+	for _, backupPath := range backups {
+		if backupPath != "" {
+			os.Remove(backupPath)
+		}
+	}
+	for _, path := range w.pruned {
+		removeEmptyDirs(w.OutputPath, filepath.Dir(path))
+	}
+	return nil
+}
+
+// backupFile moves a file aside to a temporary file next to it, returning "" if there is no such file
+func backupFile(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".bak-*")
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+	if err := os.Rename(path, file.Name()); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// writeTempFile writes content to a temporary file next to path, with the mode of the file it replaces
+func writeTempFile(path string, content []byte) (string, error) {
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	_, writeErr := file.Write(content)
+	if writeErr == nil {
+		writeErr = file.Sync()
+	}
+	closeErr := file.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr == nil {
+		writeErr = os.Chmod(file.Name(), mode)
+	}
+	if writeErr != nil {
+		os.Remove(file.Name())
+		return "", writeErr
+	}
+	return file.Name(), nil
+}
+
+// getManifestSources returns the registry files an output file is generated from, relative to the output path
+func (w *MorpheWriter) getManifestSources(relativePath string) []string {
+	var sources []string
+	for _, source := range w.sources.forOutput(relativePath) {
+		sources = append(sources, toManifestPath(w.OutputPath, source))
+	}
+	return sources
 }
 
 // Helper function to convert type names to file names
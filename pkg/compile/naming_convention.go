@@ -0,0 +1,115 @@
+package compile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+// DefaultNamingConvention is the constraint naming convention applied to the generated
+// Base metadata. Configured entries override these per constraint kind.
+var DefaultNamingConvention = map[string]string{
+	"ix": "ix_%(column_0_label)s",
+	"uq": "uq_%(table_name)s_%(column_0_N_name)s",
+	"ck": "ck_%(table_name)s_%(constraint_name)s",
+	"fk": "fk_%(table_name)s_%(column_0_name)s_%(referred_table_name)s",
+	"pk": "pk_%(table_name)s",
+}
+
+// namingConventionTokens are the SQLAlchemy convention tokens the compiler can expand
+var namingConventionTokens = map[string]bool{
+	"table_name":             true,
+	"constraint_name":        true,
+	"column_0_name":          true,
+	"column_0_label":         true,
+	"column_0_key":           true,
+	"column_0_N_name":        true,
+	"column_0N_name":         true,
+	"column_0_N_label":       true,
+	"column_0N_label":        true,
+	"column_0_N_key":         true,
+	"column_0N_key":          true,
+	"referred_table_name":    true,
+	"referred_column_0_name": true,
+}
+
+var namingConventionTokenPattern = regexp.MustCompile(`%\(([A-Za-z0-9_]+)\)s`)
+
+// getNamingConvention returns the effective naming convention for the configuration
+func getNamingConvention(config SQLAlchemyConfig) map[string]string {
+	convention := make(map[string]string, len(DefaultNamingConvention))
+	for kind, template := range DefaultNamingConvention {
+		convention[kind] = template
+	}
+	for kind, template := range config.NamingConvention {
+		convention[kind] = template
+	}
+	return convention
+}
+
+// validateNamingConvention checks that all convention kinds and tokens are known
+func validateNamingConvention(convention map[string]string) error {
+	for kind, template := range convention {
+		if _, exists := DefaultNamingConvention[kind]; !exists {
+			return fmt.Errorf("invalid naming convention key: %s (must be 'ix', 'uq', 'ck', 'fk', or 'pk')", kind)
+		}
+		for _, match := range namingConventionTokenPattern.FindAllStringSubmatch(template, -1) {
+			if !namingConventionTokens[match[1]] {
+				return fmt.Errorf("invalid naming convention token in %s: %%(%s)s", kind, match[1])
+			}
+		}
+	}
+	return nil
+}
+
+// applyNamingConvention expands a convention template for constraints of a table the way
+// SQLAlchemy does: labels are prefixed with the schema-qualified table name, and keys are
+// the column names, since every column is declared with an explicit name.
+func applyNamingConvention(template string, table *formatdef.Table, columnNames []string, refTableName string, refColumnNames []string) string {
+	labelPrefix := table.Name
+	if table.Schema != "" {
+		labelPrefix = strings.ReplaceAll(table.Schema, ".", "_") + "_" + table.Name
+	}
+
+	labels := make([]string, 0, len(columnNames))
+	for _, columnName := range columnNames {
+		labels = append(labels, labelPrefix+"_"+columnName)
+	}
+
+	values := map[string]string{
+		"table_name":          table.Name,
+		"referred_table_name": refTableName,
+		"column_0_N_name":     strings.Join(columnNames, "_"),
+		"column_0N_name":      strings.Join(columnNames, ""),
+		"column_0_N_label":    strings.Join(labels, "_"),
+		"column_0N_label":     strings.Join(labels, ""),
+		"column_0_N_key":      strings.Join(columnNames, "_"),
+		"column_0N_key":       strings.Join(columnNames, ""),
+	}
+	if len(columnNames) > 0 {
+		values["column_0_name"] = columnNames[0]
+		values["column_0_key"] = columnNames[0]
+		values["column_0_label"] = labels[0]
+	}
+	if len(refColumnNames) > 0 {
+		values["referred_column_0_name"] = refColumnNames[0]
+	}
+
+	return namingConventionTokenPattern.ReplaceAllStringFunc(template, func(token string) string {
+		name := namingConventionTokenPattern.FindStringSubmatch(token)[1]
+		return values[name]
+	})
+}
+
+// getSortedConventionKinds returns the convention kinds in a stable order
+func getSortedConventionKinds(convention map[string]string) []string {
+	var kinds []string
+	for kind := range convention {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
@@ -0,0 +1,114 @@
+package compile_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
+	"github.com/kalo-build/morphe-go/pkg/yaml"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/internal/testutils"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+type NamingConventionTestSuite struct {
+	suite.Suite
+
+	Registry *registry.Registry
+	Person   yaml.Model
+}
+
+func TestNamingConventionTestSuite(t *testing.T) {
+	suite.Run(t, new(NamingConventionTestSuite))
+}
+
+func (suite *NamingConventionTestSuite) SetupTest() {
+	registryDirPath := filepath.Join(testutils.GetTestDirPath(), "registry", "minimal")
+	r, err := registry.LoadMorpheRegistry(registry.LoadMorpheRegistryHooks{}, rcfg.MorpheLoadRegistryConfig{
+		RegistryEnumsDirPath:      filepath.Join(registryDirPath, "enums"),
+		RegistryStructuresDirPath: filepath.Join(registryDirPath, "structures"),
+		RegistryModelsDirPath:     filepath.Join(registryDirPath, "models"),
+		RegistryEntitiesDirPath:   filepath.Join(registryDirPath, "entities"),
+	})
+	suite.Require().NoError(err)
+	suite.Registry = r
+
+	person, err := r.GetModel("Person")
+	suite.Require().NoError(err)
+	suite.Person = person
+}
+
+func (suite *NamingConventionTestSuite) compilePerson(config compile.SQLAlchemyConfig) *formatdef.Table {
+	table, err := compile.CompileTable(suite.Person, config, suite.Registry)
+	suite.Require().NoError(err)
+	return table
+}
+
+func (suite *NamingConventionTestSuite) TestDefaultConvention() {
+	table := suite.compilePerson(compile.SQLAlchemyConfig{})
+
+	suite.Equal("pk_person", table.PrimaryKeyName)
+	suite.Equal("fk_person_company_id_company", table.ForeignKeys[0].Name)
+	suite.Equal("ix_person_company_id", table.Indexes[0].Name)
+	suite.Equal("uq_person_first_name_last_name", table.Uniques[0].Name)
+}
+
+func (suite *NamingConventionTestSuite) TestColumnLabel_QualifiedBySchema() {
+	config := compile.SQLAlchemyConfig{
+		DefaultSchema: "crm",
+		NamingConvention: map[string]string{
+			"uq": "uq_%(column_0_N_label)s",
+		},
+	}
+
+	table := suite.compilePerson(config)
+
+	// SQLAlchemy labels columns as <schema>_<table>_<column>
+	suite.Equal("ix_crm_person_company_id", table.Indexes[0].Name)
+	suite.Equal("uq_crm_person_first_name_crm_person_last_name", table.Uniques[0].Name)
+	// table_name stays unqualified
+	suite.Equal("pk_person", table.PrimaryKeyName)
+}
+
+func (suite *NamingConventionTestSuite) TestColumnKey_UsesColumnName() {
+	config := compile.SQLAlchemyConfig{
+		ColumnNames: map[string]map[string]string{
+			"Person": {"FirstName": "given_name"},
+		},
+		NamingConvention: map[string]string{
+			"pk": "pk_%(table_name)s_%(column_0_key)s",
+			"uq": "uq_%(table_name)s_%(column_0_N_key)s",
+			"ix": "ix_%(table_name)s_%(column_0N_key)s",
+		},
+	}
+
+	table := suite.compilePerson(config)
+
+	// Columns are keyed by their explicit names, so the id_ attribute's key is id
+	suite.Equal("pk_person_id", table.PrimaryKeyName)
+	suite.Equal("uq_person_given_name_last_name", table.Uniques[0].Name)
+	suite.Equal([]string{"given_name", "last_name"}, table.Uniques[0].Columns)
+	suite.Equal("ix_person_company_id", table.Indexes[0].Name)
+}
+
+func (suite *NamingConventionTestSuite) TestColumnName_UsesDatabaseName() {
+	config := compile.SQLAlchemyConfig{
+		ColumnNames: map[string]map[string]string{
+			"Person": {"FirstName": "given_name"},
+		},
+		NamingConvention: map[string]string{
+			"pk": "pk_%(table_name)s_%(column_0_name)s",
+			"uq": "uq_%(table_name)s_%(column_0N_name)s",
+			"fk": "fk_%(table_name)s_%(referred_table_name)s_%(referred_column_0_name)s",
+		},
+	}
+
+	table := suite.compilePerson(config)
+
+	suite.Equal("pk_person_id", table.PrimaryKeyName)
+	suite.Equal("uq_person_given_namelast_name", table.Uniques[0].Name)
+	suite.Equal("fk_person_company_id", table.ForeignKeys[0].Name)
+}
@@ -0,0 +1,175 @@
+package compile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kalo-build/morphe-go/pkg/registry"
+	rcfg "github.com/kalo-build/morphe-go/pkg/registry/cfg"
+	"github.com/kalo-build/morphe-go/pkg/yamlfile"
+)
+
+// ManifestFileName is the file under the output path recording the generated files
+const ManifestFileName = ".morphe-manifest.json"
+
+// outputManifest records the files generated by a run, so the next run can prune the ones no longer generated
+type outputManifest struct {
+	Files []manifestFile `json:"files"`
+}
+
+// manifestFile is a generated file with the hash of its content and the registry files it was generated from
+type manifestFile struct {
+	Path    string   `json:"path"`
+	Hash    string   `json:"hash"`
+	Sources []string `json:"sources,omitempty"`
+}
+
+// registrySources maps the registry kinds (enums, models, structures and entities) to the files defining each type
+type registrySources map[string]map[string]string
+
+// registryDefinition is the part of a registry file needed to find the type it defines
+type registryDefinition struct {
+	Name string `yaml:"name"`
+}
+
+// loadRegistrySources finds the registry file defining each type in the registry directories
+func loadRegistrySources(config rcfg.MorpheLoadRegistryConfig) (registrySources, error) {
+	sources := make(registrySources)
+	dirs := []struct {
+		kind   string
+		dir    string
+		suffix string
+	}{
+		{"enums", config.RegistryEnumsDirPath, registry.EnumFileSuffix},
+		{"models", config.RegistryModelsDirPath, registry.ModelFileSuffix},
+		{"structures", config.RegistryStructuresDirPath, registry.StructureFileSuffix},
+		{"entities", config.RegistryEntitiesDirPath, registry.EntityFileSuffix},
+	}
+	for _, d := range dirs {
+		sources[d.kind] = make(map[string]string)
+		// Missing directories are skipped by the registry as well
+		if info, err := os.Stat(d.dir); err != nil || !info.IsDir() {
+			continue
+		}
+		definitions, err := yamlfile.UnmarshalAllYAMLFiles[registryDefinition](d.dir, d.suffix)
+		if err != nil {
+			return nil, err
+		}
+		for filePath, definition := range definitions {
+			sources[d.kind][definition.Name] = filePath
+		}
+	}
+	return sources, nil
+}
+
+// all returns the files defining every type of a registry kind, sorted
+func (s registrySources) all(kind string) []string {
+	var files []string
+	for _, file := range s[kind] {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// forOutput returns the registry files an output file, relative to the output path, is generated from.
+// Per-type modules come from their type, other files from every type of their kind.
+func (s registrySources) forOutput(relativePath string) []string {
+	dir, file := filepath.Split(filepath.ToSlash(relativePath))
+	name := strings.TrimSuffix(file, filepath.Ext(file))
+	kind := strings.TrimSuffix(dir, "/")
+	if kind == "" {
+		kind = name
+	}
+
+	switch kind {
+	case "enums", "models", "structures", "entities", "repositories":
+	default:
+		// base.py, the SQL schema, snapshots and migrations describe the models
+		return s.all("models")
+	}
+	if kind == "repositories" {
+		kind = "entities"
+	}
+
+	if dir != "" {
+		for typeName, source := range s[kind] {
+			if toFileName(typeName) == name {
+				return []string{source}
+			}
+		}
+		if kind == "models" {
+			for entityName, source := range s["entities"] {
+				if toFileName(getViewClassName(entityName)) == name {
+					return []string{source}
+				}
+			}
+		}
+	}
+	return s.all(kind)
+}
+
+// toManifestPath returns a path relative to the output path where possible, so manifests don't depend on the working directory
+func toManifestPath(outputPath string, path string) string {
+	absOutputPath, outputErr := filepath.Abs(outputPath)
+	absPath, pathErr := filepath.Abs(path)
+	if outputErr != nil || pathErr != nil {
+		return filepath.ToSlash(path)
+	}
+	relativePath, err := filepath.Rel(absOutputPath, absPath)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(relativePath)
+}
+
+// hashContent returns the hash recorded in the manifest for file content
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// isAppendOnlyOutput reports whether generated files at a path accumulate across runs instead of being replaced
+func isAppendOnlyOutput(relativePath string) bool {
+	return strings.HasPrefix(filepath.ToSlash(relativePath), "migrations/")
+}
+
+// readManifest reads the manifest of the previous run, or returns nil if there is none
+func readManifest(outputPath string) (*outputManifest, error) {
+	data, err := os.ReadFile(filepath.Join(outputPath, ManifestFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest outputManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", ManifestFileName, err)
+	}
+	return &manifest, nil
+}
+
+// isInsideOutput reports whether a manifest path stays under the output path
+func isInsideOutput(relativePath string) bool {
+	cleaned := filepath.Clean(filepath.FromSlash(relativePath))
+	return !filepath.IsAbs(cleaned) && cleaned != "." && cleaned != ".." && !strings.HasPrefix(cleaned, ".."+string(filepath.Separator))
+}
+
+// removeEmptyDirs removes the directory and its parents while they are empty, stopping at the output path
+func removeEmptyDirs(outputPath string, dir string) {
+	root := filepath.Clean(outputPath)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
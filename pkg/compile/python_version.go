@@ -0,0 +1,24 @@
+package compile
+
+import (
+	"strconv"
+	"strings"
+)
+
+// pythonVersionAtLeast reports whether a "major.minor" target version is at least the given version.
+// Unparsable versions are treated as the 3.8 default.
+func pythonVersionAtLeast(version string, major int, minor int) bool {
+	targetMajor, targetMinor := 3, 8
+	parts := strings.Split(version, ".")
+	if len(parts) >= 2 {
+		parsedMajor, majorErr := strconv.Atoi(parts[0])
+		parsedMinor, minorErr := strconv.Atoi(parts[1])
+		if majorErr == nil && minorErr == nil {
+			targetMajor, targetMinor = parsedMajor, parsedMinor
+		}
+	}
+	if targetMajor != major {
+		return targetMajor > major
+	}
+	return targetMinor >= minor
+}
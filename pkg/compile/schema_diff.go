@@ -0,0 +1,427 @@
+package compile
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+// SchemaChangeKind identifies the kind of a schema change
+type SchemaChangeKind string
+
+const (
+	ChangeCreateTable    SchemaChangeKind = "create_table"
+	ChangeDropTable      SchemaChangeKind = "drop_table"
+	ChangeAddColumn      SchemaChangeKind = "add_column"
+	ChangeDropColumn     SchemaChangeKind = "drop_column"
+	ChangeRenameColumn   SchemaChangeKind = "rename_column"
+	ChangeAlterColumn    SchemaChangeKind = "alter_column"
+	ChangeAddForeignKey  SchemaChangeKind = "add_foreign_key"
+	ChangeDropForeignKey SchemaChangeKind = "drop_foreign_key"
+	ChangeAddUnique      SchemaChangeKind = "add_unique"
+	ChangeDropUnique     SchemaChangeKind = "drop_unique"
+	ChangeAddIndex       SchemaChangeKind = "add_index"
+	ChangeDropIndex      SchemaChangeKind = "drop_index"
+	ChangeCreateEnum     SchemaChangeKind = "create_enum"
+	ChangeDropEnum       SchemaChangeKind = "drop_enum"
+	ChangeAddEnumMember  SchemaChangeKind = "add_enum_member"
+	ChangeDropEnumMember SchemaChangeKind = "drop_enum_member"
+	ChangeCreateView     SchemaChangeKind = "create_view"
+	ChangeDropView       SchemaChangeKind = "drop_view"
+)
+
+// SchemaChange represents a single difference between two schemas
+type SchemaChange struct {
+	Kind  SchemaChangeKind
+	Table formatdef.Table // Table the change applies to (full definition for create/drop)
+
+	OldColumn  formatdef.Column
+	NewColumn  formatdef.Column
+	ForeignKey formatdef.ForeignKey
+	Unique     formatdef.UniqueConstraint
+	Index      formatdef.Index
+
+	Enum   formatdef.EnumDef
+	Member string
+
+	View formatdef.View
+}
+
+// IsDestructive reports whether applying the change can lose data
+func (c SchemaChange) IsDestructive() bool {
+	switch c.Kind {
+	case ChangeDropTable, ChangeDropColumn, ChangeDropEnum, ChangeDropEnumMember:
+		return true
+	case ChangeAlterColumn:
+		return c.OldColumn.Type != c.NewColumn.Type || c.OldColumn.EnumName != c.NewColumn.EnumName
+	}
+	return false
+}
+
+// NeedsReview reports whether the change is safe on an empty table but may fail or
+// misbehave on existing data
+func (c SchemaChange) NeedsReview() bool {
+	switch c.Kind {
+	case ChangeRenameColumn, ChangeAddUnique:
+		return true
+	case ChangeAddColumn:
+		return !c.NewColumn.Nullable && !c.NewColumn.PrimaryKey
+	case ChangeAlterColumn:
+		return c.OldColumn.Nullable && !c.NewColumn.Nullable
+	}
+	return false
+}
+
+// Invert returns the change that undoes this change
+func (c SchemaChange) Invert() SchemaChange {
+	inverted := c
+	switch c.Kind {
+	case ChangeCreateTable:
+		inverted.Kind = ChangeDropTable
+	case ChangeDropTable:
+		inverted.Kind = ChangeCreateTable
+	case ChangeAddColumn:
+		inverted.Kind = ChangeDropColumn
+		inverted.OldColumn, inverted.NewColumn = c.NewColumn, c.OldColumn
+	case ChangeDropColumn:
+		inverted.Kind = ChangeAddColumn
+		inverted.OldColumn, inverted.NewColumn = c.NewColumn, c.OldColumn
+	case ChangeRenameColumn, ChangeAlterColumn:
+		inverted.OldColumn, inverted.NewColumn = c.NewColumn, c.OldColumn
+	case ChangeAddForeignKey:
+		inverted.Kind = ChangeDropForeignKey
+	case ChangeDropForeignKey:
+		inverted.Kind = ChangeAddForeignKey
+	case ChangeAddUnique:
+		inverted.Kind = ChangeDropUnique
+	case ChangeDropUnique:
+		inverted.Kind = ChangeAddUnique
+	case ChangeAddIndex:
+		inverted.Kind = ChangeDropIndex
+	case ChangeDropIndex:
+		inverted.Kind = ChangeAddIndex
+	case ChangeCreateEnum:
+		inverted.Kind = ChangeDropEnum
+	case ChangeDropEnum:
+		inverted.Kind = ChangeCreateEnum
+	case ChangeAddEnumMember:
+		inverted.Kind = ChangeDropEnumMember
+	case ChangeDropEnumMember:
+		inverted.Kind = ChangeAddEnumMember
+	case ChangeCreateView:
+		inverted.Kind = ChangeDropView
+	case ChangeDropView:
+		inverted.Kind = ChangeCreateView
+	}
+	return inverted
+}
+
+// DiffSchemas computes the ordered changes that migrate the previous schema to the current one
+func DiffSchemas(previous *formatdef.Schema, current *formatdef.Schema) []SchemaChange {
+	var (
+		dropForeignKeys []SchemaChange
+		dropUniques     []SchemaChange
+		dropIndexes     []SchemaChange
+		createEnums     []SchemaChange
+		addMembers      []SchemaChange
+		createTables    []SchemaChange
+		columnChanges   []SchemaChange
+		dropColumns     []SchemaChange
+		dropTables      []SchemaChange
+		dropMembers     []SchemaChange
+		dropEnums       []SchemaChange
+		addUniques      []SchemaChange
+		addIndexes      []SchemaChange
+		addForeignKeys  []SchemaChange
+		dropViews       []SchemaChange
+		createViews     []SchemaChange
+	)
+
+	// New tables are created in dependency order, cyclic foreign keys are added afterwards
+	var newTables []formatdef.Table
+	for _, table := range current.Tables {
+		if _, exists := previous.GetTable(table.QualifiedName()); !exists {
+			newTables = append(newTables, table)
+		}
+	}
+	orderedNew, deferredNew := orderTablesByDependency(newTables)
+	for _, table := range orderedNew {
+		createTables = append(createTables, SchemaChange{Kind: ChangeCreateTable, Table: table})
+		for _, index := range table.Indexes {
+			addIndexes = append(addIndexes, SchemaChange{Kind: ChangeAddIndex, Table: table, Index: index})
+		}
+	}
+	for _, deferred := range deferredNew {
+		addForeignKeys = append(addForeignKeys, SchemaChange{
+			Kind:       ChangeAddForeignKey,
+			Table:      deferred.Table,
+			ForeignKey: deferred.ForeignKey,
+		})
+	}
+
+	// Removed tables are dropped in reverse dependency order
+	var oldTables []formatdef.Table
+	for _, table := range previous.Tables {
+		if _, exists := current.GetTable(table.QualifiedName()); !exists {
+			oldTables = append(oldTables, table)
+		}
+	}
+	orderedOld, deferredOld := orderTablesByDependency(oldTables)
+	for i := len(orderedOld) - 1; i >= 0; i-- {
+		dropTables = append(dropTables, SchemaChange{Kind: ChangeDropTable, Table: orderedOld[i]})
+		for _, index := range orderedOld[i].Indexes {
+			dropIndexes = append(dropIndexes, SchemaChange{Kind: ChangeDropIndex, Table: orderedOld[i], Index: index})
+		}
+	}
+	for _, deferred := range deferredOld {
+		dropForeignKeys = append(dropForeignKeys, SchemaChange{
+			Kind:       ChangeDropForeignKey,
+			Table:      deferred.Table,
+			ForeignKey: deferred.ForeignKey,
+		})
+	}
+
+	// Tables present in both schemas are compared column by column
+	for _, newTable := range current.Tables {
+		oldTable, exists := previous.GetTable(newTable.QualifiedName())
+		if !exists {
+			continue
+		}
+		added, dropped, altered := diffColumns(oldTable, newTable)
+		columnChanges = append(columnChanges, added...)
+		columnChanges = append(columnChanges, altered...)
+		dropColumns = append(dropColumns, dropped...)
+
+		for _, oldFk := range oldTable.ForeignKeys {
+			if newFk, exists := newTable.GetForeignKey(oldFk.Column); !exists || newFk != oldFk {
+				dropForeignKeys = append(dropForeignKeys, SchemaChange{Kind: ChangeDropForeignKey, Table: newTable, ForeignKey: oldFk})
+			}
+		}
+		for _, newFk := range newTable.ForeignKeys {
+			if oldFk, exists := oldTable.GetForeignKey(newFk.Column); !exists || newFk != oldFk {
+				addForeignKeys = append(addForeignKeys, SchemaChange{Kind: ChangeAddForeignKey, Table: newTable, ForeignKey: newFk})
+			}
+		}
+
+		for _, oldUnique := range oldTable.Uniques {
+			if newUnique, exists := newTable.GetUnique(oldUnique.Name); !exists || !equalStrings(newUnique.Columns, oldUnique.Columns) {
+				dropUniques = append(dropUniques, SchemaChange{Kind: ChangeDropUnique, Table: newTable, Unique: oldUnique})
+			}
+		}
+		for _, newUnique := range newTable.Uniques {
+			if oldUnique, exists := oldTable.GetUnique(newUnique.Name); !exists || !equalStrings(newUnique.Columns, oldUnique.Columns) {
+				addUniques = append(addUniques, SchemaChange{Kind: ChangeAddUnique, Table: newTable, Unique: newUnique})
+			}
+		}
+
+		for _, oldIndex := range oldTable.Indexes {
+			if newIndex, exists := newTable.GetIndex(oldIndex.Name); !exists || !equalStrings(newIndex.Columns, oldIndex.Columns) {
+				dropIndexes = append(dropIndexes, SchemaChange{Kind: ChangeDropIndex, Table: newTable, Index: oldIndex})
+			}
+		}
+		for _, newIndex := range newTable.Indexes {
+			if oldIndex, exists := oldTable.GetIndex(newIndex.Name); !exists || !equalStrings(newIndex.Columns, oldIndex.Columns) {
+				addIndexes = append(addIndexes, SchemaChange{Kind: ChangeAddIndex, Table: newTable, Index: newIndex})
+			}
+		}
+	}
+
+	// Enum types and their members
+	for _, newEnum := range current.Enums {
+		oldEnum, exists := previous.GetEnum(newEnum.Name)
+		if !exists {
+			createEnums = append(createEnums, SchemaChange{Kind: ChangeCreateEnum, Enum: newEnum})
+			continue
+		}
+		for _, member := range newEnum.Members {
+			if !containsString(oldEnum.Members, member) {
+				addMembers = append(addMembers, SchemaChange{Kind: ChangeAddEnumMember, Enum: newEnum, Member: member})
+			}
+		}
+		for _, member := range oldEnum.Members {
+			if !containsString(newEnum.Members, member) {
+				dropMembers = append(dropMembers, SchemaChange{Kind: ChangeDropEnumMember, Enum: newEnum, Member: member})
+			}
+		}
+	}
+	for _, oldEnum := range previous.Enums {
+		if _, exists := current.GetEnum(oldEnum.Name); !exists {
+			dropEnums = append(dropEnums, SchemaChange{Kind: ChangeDropEnum, Enum: oldEnum})
+		}
+	}
+
+	// Views are recreated when their definition or any table they select from changes
+	changedTables := make(map[string]bool)
+	for _, group := range [][]SchemaChange{dropForeignKeys, dropUniques, dropIndexes, createTables, columnChanges, dropColumns, dropTables, addUniques, addIndexes, addForeignKeys} {
+		for _, change := range group {
+			changedTables[change.Table.QualifiedName()] = true
+		}
+	}
+	for _, group := range [][]SchemaChange{addMembers, dropMembers, dropEnums} {
+		for _, change := range group {
+			for _, table := range current.Tables {
+				for _, column := range table.Columns {
+					if column.EnumName == change.Enum.Name {
+						changedTables[table.QualifiedName()] = true
+					}
+				}
+			}
+		}
+	}
+	for _, oldView := range previous.Views {
+		if newView, exists := current.GetView(oldView.QualifiedName()); !exists || !reflect.DeepEqual(oldView, newView) || selectsFromAny(oldView, changedTables) {
+			dropViews = append(dropViews, SchemaChange{Kind: ChangeDropView, View: oldView})
+		}
+	}
+	for _, newView := range current.Views {
+		if oldView, exists := previous.GetView(newView.QualifiedName()); !exists || !reflect.DeepEqual(oldView, newView) || selectsFromAny(newView, changedTables) {
+			createViews = append(createViews, SchemaChange{Kind: ChangeCreateView, View: newView})
+		}
+	}
+
+	// Constraints are dropped before and created after the column and table changes
+	var changes []SchemaChange
+	for _, group := range [][]SchemaChange{
+		dropViews,
+		dropForeignKeys, dropUniques, dropIndexes,
+		createEnums, addMembers,
+		createTables, columnChanges, dropColumns, dropTables,
+		dropMembers, dropEnums,
+		addUniques, addIndexes, addForeignKeys,
+		createViews,
+	} {
+		changes = append(changes, group...)
+	}
+	return changes
+}
+
+// selectsFromAny reports whether a view selects from any of the tables
+func selectsFromAny(view formatdef.View, tables map[string]bool) bool {
+	for _, name := range view.TableNames() {
+		if tables[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// InvertChanges returns the changes that undo the given changes, in reverse order
+func InvertChanges(changes []SchemaChange) []SchemaChange {
+	inverted := make([]SchemaChange, 0, len(changes))
+	for i := len(changes) - 1; i >= 0; i-- {
+		inverted = append(inverted, changes[i].Invert())
+	}
+	return inverted
+}
+
+// diffColumns compares the columns of a table present in both schemas
+func diffColumns(oldTable formatdef.Table, newTable formatdef.Table) (added []SchemaChange, dropped []SchemaChange, altered []SchemaChange) {
+	var addedColumns []formatdef.Column
+	var droppedColumns []formatdef.Column
+
+	for _, newColumn := range newTable.Columns {
+		oldColumn, exists := oldTable.GetColumn(newColumn.Name)
+		if !exists {
+			addedColumns = append(addedColumns, newColumn)
+			continue
+		}
+		if oldColumn.Type != newColumn.Type || oldColumn.EnumName != newColumn.EnumName || oldColumn.Nullable != newColumn.Nullable {
+			altered = append(altered, SchemaChange{Kind: ChangeAlterColumn, Table: newTable, OldColumn: oldColumn, NewColumn: newColumn})
+		}
+	}
+	for _, oldColumn := range oldTable.Columns {
+		if _, exists := newTable.GetColumn(oldColumn.Name); !exists {
+			droppedColumns = append(droppedColumns, oldColumn)
+		}
+	}
+
+	// A single dropped and added column of the same type is most likely a rename
+	if len(addedColumns) == 1 && len(droppedColumns) == 1 &&
+		addedColumns[0].Type == droppedColumns[0].Type && addedColumns[0].EnumName == droppedColumns[0].EnumName {
+		altered = append(altered, SchemaChange{Kind: ChangeRenameColumn, Table: newTable, OldColumn: droppedColumns[0], NewColumn: addedColumns[0]})
+		return nil, nil, altered
+	}
+
+	for _, column := range addedColumns {
+		added = append(added, SchemaChange{Kind: ChangeAddColumn, Table: newTable, NewColumn: column})
+	}
+	for _, column := range droppedColumns {
+		dropped = append(dropped, SchemaChange{Kind: ChangeDropColumn, Table: newTable, OldColumn: column})
+	}
+	return added, dropped, altered
+}
+
+// DeferredForeignKey is a foreign key that must be added after its table is created
+type DeferredForeignKey struct {
+	Table      formatdef.Table
+	ForeignKey formatdef.ForeignKey
+}
+
+// orderTablesByDependency sorts tables so that referenced tables come first.
+// Tables are keyed by their schema-qualified name, so equally named tables in different schemas stay apart.
+// Foreign keys that close a cycle are removed from their table and returned separately.
+func orderTablesByDependency(tables []formatdef.Table) ([]formatdef.Table, []DeferredForeignKey) {
+	byName := make(map[string]formatdef.Table)
+	var names []string
+	for _, table := range tables {
+		byName[table.QualifiedName()] = table
+		names = append(names, table.QualifiedName())
+	}
+	sort.Strings(names)
+
+	var ordered []formatdef.Table
+	var deferred []DeferredForeignKey
+	visited := make(map[string]bool)
+	inProgress := make(map[string]bool)
+
+	var visit func(name string)
+	visit = func(name string) {
+		visited[name] = true
+		inProgress[name] = true
+
+		table := byName[name]
+		var keptForeignKeys []formatdef.ForeignKey
+		for _, fk := range table.ForeignKeys {
+			refName := fk.QualifiedRefTable()
+			if _, inSet := byName[refName]; !inSet || refName == name {
+				// References outside the set or to itself never block creation
+				keptForeignKeys = append(keptForeignKeys, fk)
+				continue
+			}
+			if inProgress[refName] {
+				deferred = append(deferred, DeferredForeignKey{Table: table, ForeignKey: fk})
+				continue
+			}
+			if !visited[refName] {
+				visit(refName)
+			}
+			keptForeignKeys = append(keptForeignKeys, fk)
+		}
+		table.ForeignKeys = keptForeignKeys
+
+		inProgress[name] = false
+		ordered = append(ordered, table)
+	}
+
+	for _, name := range names {
+		if !visited[name] {
+			visit(name)
+		}
+	}
+
+	return ordered, deferred
+}
+
+// equalStrings checks if two string slices hold the same values in the same order
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+package compile_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/compile"
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+type SchemaDiffTestSuite struct {
+	suite.Suite
+}
+
+func TestSchemaDiffTestSuite(t *testing.T) {
+	suite.Run(t, new(SchemaDiffTestSuite))
+}
+
+func (suite *SchemaDiffTestSuite) personTable(columns ...formatdef.Column) formatdef.Table {
+	return formatdef.Table{
+		Name:      "person",
+		ModelName: "Person",
+		Columns: append([]formatdef.Column{
+			{Name: "id", Type: "Integer", PrimaryKey: true, AutoIncrement: true},
+		}, columns...),
+	}
+}
+
+func (suite *SchemaDiffTestSuite) kinds(changes []compile.SchemaChange) []compile.SchemaChangeKind {
+	var kinds []compile.SchemaChangeKind
+	for _, change := range changes {
+		kinds = append(kinds, change.Kind)
+	}
+	return kinds
+}
+
+func (suite *SchemaDiffTestSuite) TestDiffSchemas_NoChanges() {
+	schema := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable()}}
+
+	changes := compile.DiffSchemas(schema, schema)
+
+	suite.Empty(changes)
+}
+
+func (suite *SchemaDiffTestSuite) TestDiffSchemas_CreateTablesInDependencyOrder() {
+	person := suite.personTable(formatdef.Column{Name: "company_id", Type: "Integer"})
+	person.ForeignKeys = []formatdef.ForeignKey{
+		{Name: "fk_person_company_id_company", Column: "company_id", RefTable: "company", RefColumn: "id"},
+	}
+	company := formatdef.Table{
+		Name: "company",
+		Columns: []formatdef.Column{
+			{Name: "id", Type: "Integer", PrimaryKey: true},
+			{Name: "owner_id", Type: "Integer"},
+		},
+		ForeignKeys: []formatdef.ForeignKey{
+			{Name: "fk_company_owner_id_person", Column: "owner_id", RefTable: "person", RefColumn: "id"},
+		},
+	}
+	current := &formatdef.Schema{Tables: []formatdef.Table{person, company}}
+
+	changes := compile.DiffSchemas(&formatdef.Schema{}, current)
+
+	suite.Equal([]compile.SchemaChangeKind{
+		compile.ChangeCreateTable,
+		compile.ChangeCreateTable,
+		compile.ChangeAddForeignKey,
+	}, suite.kinds(changes))
+	suite.Equal("person", changes[0].Table.Name)
+	suite.Empty(changes[0].Table.ForeignKeys, "cyclic foreign key should be deferred")
+	suite.Equal("company", changes[1].Table.Name)
+	suite.Len(changes[1].Table.ForeignKeys, 1)
+	suite.Equal("fk_person_company_id_company", changes[2].ForeignKey.Name)
+}
+
+func (suite *SchemaDiffTestSuite) TestDiffSchemas_ColumnChanges() {
+	previous := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable(
+		formatdef.Column{Name: "age", Type: "Integer"},
+		formatdef.Column{Name: "email", Type: "String", Nullable: true},
+		formatdef.Column{Name: "nickname", Type: "String"},
+	)}}
+	current := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable(
+		formatdef.Column{Name: "age", Type: "String"},
+		formatdef.Column{Name: "email", Type: "String"},
+		formatdef.Column{Name: "birth_date", Type: "DateTime", Nullable: true},
+		formatdef.Column{Name: "score", Type: "Float", Nullable: true},
+	)}}
+
+	changes := compile.DiffSchemas(previous, current)
+
+	suite.Equal([]compile.SchemaChangeKind{
+		compile.ChangeAddColumn,
+		compile.ChangeAddColumn,
+		compile.ChangeAlterColumn,
+		compile.ChangeAlterColumn,
+		compile.ChangeDropColumn,
+	}, suite.kinds(changes))
+	suite.True(changes[2].IsDestructive(), "type change should be destructive")
+	suite.False(changes[3].IsDestructive())
+	suite.True(changes[3].NeedsReview(), "making a column non-nullable should need review")
+	suite.True(changes[4].IsDestructive())
+	suite.Equal("nickname", changes[4].OldColumn.Name)
+}
+
+func (suite *SchemaDiffTestSuite) TestDiffSchemas_RenameColumn() {
+	previous := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable(
+		formatdef.Column{Name: "surname", Type: "String"},
+	)}}
+	current := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable(
+		formatdef.Column{Name: "last_name", Type: "String"},
+	)}}
+
+	changes := compile.DiffSchemas(previous, current)
+
+	suite.Equal([]compile.SchemaChangeKind{compile.ChangeRenameColumn}, suite.kinds(changes))
+	suite.Equal("surname", changes[0].OldColumn.Name)
+	suite.Equal("last_name", changes[0].NewColumn.Name)
+
+	inverted := compile.InvertChanges(changes)
+	suite.Equal("last_name", inverted[0].OldColumn.Name)
+	suite.Equal("surname", inverted[0].NewColumn.Name)
+}
+
+func (suite *SchemaDiffTestSuite) TestDiffSchemas_EnumMembers() {
+	previous := &formatdef.Schema{Enums: []formatdef.EnumDef{
+		{Name: "Nationality", TypeName: "nationality", Members: []string{"DE", "IT"}},
+	}}
+	current := &formatdef.Schema{Enums: []formatdef.EnumDef{
+		{Name: "Nationality", TypeName: "nationality", Members: []string{"DE", "FR"}},
+	}}
+
+	changes := compile.DiffSchemas(previous, current)
+
+	suite.Equal([]compile.SchemaChangeKind{
+		compile.ChangeAddEnumMember,
+		compile.ChangeDropEnumMember,
+	}, suite.kinds(changes))
+	suite.Equal("FR", changes[0].Member)
+	suite.Equal("IT", changes[1].Member)
+	suite.True(changes[1].IsDestructive())
+}
+
+func (suite *SchemaDiffTestSuite) TestDiffSchemas_Views() {
+	view := formatdef.View{
+		Name:       "person_view",
+		EntityName: "Person",
+		From:       "person",
+		Columns: []formatdef.ViewColumn{
+			{Column: formatdef.Column{Name: "id", Type: "Integer", PrimaryKey: true}, Source: "person", SourceColumn: "id"},
+		},
+	}
+	previous := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable()}}
+	current := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable()}, Views: []formatdef.View{view}}
+
+	created := compile.DiffSchemas(previous, current)
+	suite.Equal([]compile.SchemaChangeKind{compile.ChangeCreateView}, suite.kinds(created))
+	suite.Equal("person_view", created[0].View.Name)
+
+	suite.Empty(compile.DiffSchemas(current, current))
+
+	// A view is recreated around changes to a table it selects from
+	changed := &formatdef.Schema{
+		Tables: []formatdef.Table{suite.personTable(formatdef.Column{Name: "age", Type: "Integer"})},
+		Views:  []formatdef.View{view},
+	}
+	suite.Equal([]compile.SchemaChangeKind{
+		compile.ChangeDropView,
+		compile.ChangeAddColumn,
+		compile.ChangeCreateView,
+	}, suite.kinds(compile.DiffSchemas(current, changed)))
+}
+
+func (suite *SchemaDiffTestSuite) TestDiffSchemas_TablesKeyedBySchema() {
+	audit := suite.personTable()
+	audit.Schema = "audit"
+	previous := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable()}}
+	current := &formatdef.Schema{Tables: []formatdef.Table{suite.personTable(), audit}}
+
+	changes := compile.DiffSchemas(previous, current)
+
+	suite.Equal([]compile.SchemaChangeKind{compile.ChangeCreateTable}, suite.kinds(changes))
+	suite.Equal("audit.person", changes[0].Table.QualifiedName())
+
+	moved := &formatdef.Schema{Tables: []formatdef.Table{audit}}
+	suite.Equal([]compile.SchemaChangeKind{
+		compile.ChangeCreateTable,
+		compile.ChangeDropTable,
+	}, suite.kinds(compile.DiffSchemas(previous, moved)))
+}
+
+func (suite *SchemaDiffTestSuite) TestDiffSchemas_CreateTablesAcrossSchemas() {
+	// Same-named tables in different schemas must not be confused when ordering
+	archived := formatdef.Table{
+		Name:   "person",
+		Schema: "archive",
+		Columns: []formatdef.Column{
+			{Name: "id", Type: "Integer", PrimaryKey: true},
+			{Name: "current_id", Type: "Integer"},
+		},
+		ForeignKeys: []formatdef.ForeignKey{
+			{Name: "fk_person_current_id_person", Column: "current_id", RefTable: "person", RefColumn: "id"},
+		},
+	}
+	current := &formatdef.Schema{Tables: []formatdef.Table{archived, suite.personTable()}}
+
+	changes := compile.DiffSchemas(&formatdef.Schema{}, current)
+
+	suite.Equal([]compile.SchemaChangeKind{
+		compile.ChangeCreateTable,
+		compile.ChangeCreateTable,
+	}, suite.kinds(changes))
+	suite.Equal("person", changes[0].Table.QualifiedName())
+	suite.Equal("archive.person", changes[1].Table.QualifiedName())
+	suite.Len(changes[1].Table.ForeignKeys, 1)
+}
@@ -0,0 +1,236 @@
+package compile
+
+import (
+	"sort"
+	"strings"
+)
+
+// pythonImport is a "from module import names" statement, or an "import module" statement when Names is empty
+type pythonImport struct {
+	Module string
+	Names  []string
+}
+
+// pythonModule is a generated module split into its imports and the code after them
+type pythonModule struct {
+	Name                string
+	Imports             []pythonImport
+	TypeCheckingImports []pythonImport
+	Body                []string
+}
+
+// parsePythonModule splits generated module content into its header, import block and body.
+// The header comments are dropped, as the merged module gets its own.
+func parsePythonModule(name string, content []byte) pythonModule {
+	module := pythonModule{Name: name}
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+
+	i := 0
+	for i < len(lines) && (strings.HasPrefix(lines[i], "#") || strings.TrimSpace(lines[i]) == "") {
+		i++
+	}
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line == "if TYPE_CHECKING:" {
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], " ") {
+				i++
+				if imp, ok := parsePythonImport(strings.TrimSpace(lines[i])); ok {
+					module.TypeCheckingImports = append(module.TypeCheckingImports, imp)
+				}
+			}
+			continue
+		}
+		imp, ok := parsePythonImport(line)
+		if !ok {
+			break
+		}
+		module.Imports = append(module.Imports, imp)
+	}
+	module.Body = lines[i:]
+	return module
+}
+
+// parsePythonImport parses a single line import statement
+func parsePythonImport(line string) (pythonImport, bool) {
+	if rest, ok := strings.CutPrefix(line, "import "); ok {
+		return pythonImport{Module: strings.TrimSpace(rest)}, true
+	}
+	rest, ok := strings.CutPrefix(line, "from ")
+	if !ok {
+		return pythonImport{}, false
+	}
+	moduleName, names, ok := strings.Cut(rest, " import ")
+	if !ok {
+		return pythonImport{}, false
+	}
+	imp := pythonImport{Module: moduleName}
+	for _, importName := range strings.Split(names, ",") {
+		imp.Names = append(imp.Names, strings.TrimSpace(importName))
+	}
+	return imp, true
+}
+
+// render returns the import statement
+func (imp pythonImport) render() string {
+	if len(imp.Names) == 0 {
+		return "import " + imp.Module
+	}
+	return "from " + imp.Module + " import " + strings.Join(imp.Names, ", ")
+}
+
+// singleFileModule resolves a module imported from <category>/<file>.py against the single file layout,
+// where every category is one module next to base.py. Imports of a module merged into the same file
+// are reported with the file name of that module instead.
+func singleFileModule(category string, moduleName string, localModules map[string]string) (string, bool) {
+	trimmed := strings.TrimLeft(moduleName, ".")
+	dots := len(moduleName) - len(trimmed)
+	if dots == 0 || dots > 2 {
+		return moduleName, false
+	}
+
+	var path []string
+	if dots == 1 {
+		path = append(path, category)
+	}
+	if trimmed != "" {
+		path = append(path, strings.Split(trimmed, ".")...)
+	}
+	if len(path) >= 2 && path[0] == category {
+		if _, isLocal := localModules[path[1]]; isLocal {
+			return path[1], true
+		}
+		// Siblings that aren't part of the category live next to it, e.g. base
+		path = path[1:]
+	}
+	if len(path) == 0 {
+		return ".", false
+	}
+	// Every module of another category is merged into that category's module
+	return "." + path[0], false
+}
+
+// mergePythonModules merges generated modules of a category into the content of a single module.
+// Imports are merged and resolved against the single file layout, and modules are ordered so
+// everything a module imports at runtime from the same file is defined before it.
+func mergePythonModules(category string, contents map[string][]byte) []byte {
+	localModules := make(map[string]string)
+	modules := make(map[string]pythonModule)
+	var names []string
+	for name, content := range contents {
+		localModules[toFileName(name)] = name
+		modules[name] = parsePythonModule(name, content)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var imports, typeCheckingImports []pythonImport
+	dependencies := make(map[string]map[string]bool)
+	for _, name := range names {
+		module := modules[name]
+		dependencies[name] = make(map[string]bool)
+		for _, imp := range module.Imports {
+			if resolved, isLocal := singleFileModule(category, imp.Module, localModules); isLocal {
+				dependencies[name][localModules[resolved]] = true
+			} else {
+				imports = addPythonImport(imports, pythonImport{Module: resolved, Names: imp.Names})
+			}
+		}
+		for _, imp := range module.TypeCheckingImports {
+			if resolved, isLocal := singleFileModule(category, imp.Module, localModules); !isLocal {
+				typeCheckingImports = addPythonImport(typeCheckingImports, pythonImport{Module: resolved, Names: imp.Names})
+			}
+		}
+	}
+
+	var lines []string
+	for _, imp := range imports {
+		lines = append(lines, imp.render())
+	}
+	if len(typeCheckingImports) > 0 {
+		lines = append(lines, "", "if TYPE_CHECKING:")
+		for _, imp := range typeCheckingImports {
+			lines = append(lines, "    "+imp.render())
+		}
+	}
+
+	for _, name := range sortModulesByDependency(names, dependencies) {
+		body := modules[name].Body
+		for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+			body = body[1:]
+		}
+		for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+			body = body[:len(body)-1]
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "", "")
+		}
+		for _, line := range body {
+			// Imports inside functions are resolved like the module imports
+			if imp, ok := parsePythonImport(strings.TrimSpace(line)); ok && strings.HasPrefix(line, " ") {
+				resolved, isLocal := singleFileModule(category, imp.Module, localModules)
+				if isLocal {
+					continue
+				}
+				indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+				line = indent + pythonImport{Module: resolved, Names: imp.Names}.render()
+			}
+			lines = append(lines, line)
+		}
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// addPythonImport merges an import into the imports, keeping the order modules are first imported in
+func addPythonImport(imports []pythonImport, imp pythonImport) []pythonImport {
+	for i := range imports {
+		if imports[i].Module != imp.Module || (len(imports[i].Names) == 0) != (len(imp.Names) == 0) {
+			continue
+		}
+		for _, importName := range imp.Names {
+			if !containsString(imports[i].Names, importName) {
+				imports[i].Names = append(imports[i].Names, importName)
+			}
+		}
+		return imports
+	}
+	return append(imports, pythonImport{Module: imp.Module, Names: append([]string(nil), imp.Names...)})
+}
+
+// sortModulesByDependency orders sorted module names so dependencies come first.
+// Modules in a dependency cycle keep their alphabetical order.
+func sortModulesByDependency(names []string, dependencies map[string]map[string]bool) []string {
+	var ordered []string
+	emitted := make(map[string]bool)
+	for len(ordered) < len(names) {
+		next, firstRemaining := "", ""
+		for _, name := range names {
+			if emitted[name] {
+				continue
+			}
+			if firstRemaining == "" {
+				firstRemaining = name
+			}
+			ready := true
+			for dependency := range dependencies[name] {
+				if !emitted[dependency] && dependency != name {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = name
+				break
+			}
+		}
+		// Break cycles with the first remaining module
+		if next == "" {
+			next = firstRemaining
+		}
+		emitted[next] = true
+		ordered = append(ordered, next)
+	}
+	return ordered
+}
@@ -5,6 +5,12 @@ import (
 	"strings"
 )
 
+// Markers of the regions in generated files that keep hand-written code across regenerations
+const (
+	CustomRegionBegin = "# morphe:custom-begin"
+	CustomRegionEnd   = "# morphe:custom-end"
+)
+
 // ContentBuilder helps generate formatted code with proper indentation
 type ContentBuilder struct {
 	lines       []string
@@ -74,6 +80,14 @@ func (b *ContentBuilder) AppendToLastLine(text string) *ContentBuilder {
 	return b
 }
 
+// CustomRegion adds an empty region for hand-written code, kept verbatim when the file is regenerated
+func (b *ContentBuilder) CustomRegion() *ContentBuilder {
+	b.Line("")
+	b.Line(CustomRegionBegin)
+	b.Line(CustomRegionEnd)
+	return b
+}
+
 // Build returns the final content as a byte array
 func (b *ContentBuilder) Build() []byte {
 	return []byte(strings.Join(b.lines, "\n"))
@@ -0,0 +1,134 @@
+package formatdef
+
+import (
+	"regexp"
+	"strings"
+)
+
+// irregularPlurals are English nouns that don't follow the suffix rules
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"man":    "men",
+	"woman":  "women",
+	"child":  "children",
+	"tooth":  "teeth",
+	"foot":   "feet",
+	"mouse":  "mice",
+	"goose":  "geese",
+	"ox":     "oxen",
+}
+
+// uncountableNouns have the same singular and plural form
+var uncountableNouns = map[string]bool{
+	"data":        true,
+	"equipment":   true,
+	"feedback":    true,
+	"fish":        true,
+	"info":        true,
+	"information": true,
+	"metadata":    true,
+	"money":       true,
+	"news":        true,
+	"series":      true,
+	"sheep":       true,
+	"species":     true,
+	"staff":       true,
+}
+
+// pluralRule rewrites a singular suffix to its plural form
+type pluralRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// pluralRules are applied in order, the first matching rule wins
+var pluralRules = []pluralRule{
+	{regexp.MustCompile(`(quiz)$`), "${1}zes"},
+	{regexp.MustCompile(`(matr|vert|ind)(?:ix|ex)$`), "${1}ices"},
+	{regexp.MustCompile(`(analy|ba|diagno|parenthe|progno|synop|the)sis$`), "${1}ses"},
+	{regexp.MustCompile(`(x|ch|ss|sh|s|z)$`), "${1}es"},
+	{regexp.MustCompile(`([^aeiouy])y$`), "${1}ies"},
+	{regexp.MustCompile(`([^f])fe$`), "${1}ves"},
+	{regexp.MustCompile(`([lr])f$`), "${1}ves"},
+	{regexp.MustCompile(`([ti])um$`), "${1}a"},
+}
+
+// singularSuffixes end singular words that would otherwise look plural
+var singularSuffixes = []string{"ss", "us", "is"}
+
+// Pluralize returns the English plural of a lower case word. For snake_case
+// identifiers only the last word is inflected, and words that are already
+// plural are returned unchanged. Entries in irregulars take precedence over the
+// built-in rules and may match the whole identifier or its last word.
+func Pluralize(word string, irregulars map[string]string) string {
+	if plural, exists := irregulars[word]; exists {
+		return plural
+	}
+
+	prefix, last := splitLastWord(word)
+	if last == "" {
+		return word
+	}
+
+	if plural, exists := irregulars[last]; exists {
+		return prefix + plural
+	}
+	if plural, exists := irregularPlurals[last]; exists {
+		return prefix + plural
+	}
+	if IsPlural(word, irregulars) {
+		return word
+	}
+
+	for _, rule := range pluralRules {
+		if rule.pattern.MatchString(last) {
+			return prefix + rule.pattern.ReplaceAllString(last, rule.replacement)
+		}
+	}
+	return prefix + last + "s"
+}
+
+// IsPlural reports whether the last word of a lower case snake_case identifier
+// is already plural: a known plural, an uncountable noun or a regular plural
+// ending in "s".
+func IsPlural(word string, irregulars map[string]string) bool {
+	_, last := splitLastWord(word)
+	if _, exists := irregulars[last]; exists {
+		return false
+	}
+	if _, exists := irregularPlurals[last]; exists {
+		return false
+	}
+	if uncountableNouns[last] || isKnownPlural(word, irregulars) || isKnownPlural(last, irregulars) {
+		return true
+	}
+	if len(last) < 3 || !strings.HasSuffix(last, "s") {
+		return false
+	}
+	for _, suffix := range singularSuffixes {
+		if strings.HasSuffix(last, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// isKnownPlural reports whether a word is the plural of a built-in or configured irregular
+func isKnownPlural(word string, irregulars map[string]string) bool {
+	for _, plurals := range []map[string]string{irregulars, irregularPlurals} {
+		for _, plural := range plurals {
+			if plural == word {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitLastWord splits a snake_case identifier before its last word
+func splitLastWord(word string) (string, string) {
+	if i := strings.LastIndex(word, "_"); i >= 0 {
+		return word[:i+1], word[i+1:]
+	}
+	return "", word
+}
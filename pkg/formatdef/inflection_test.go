@@ -0,0 +1,122 @@
+package formatdef_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
+)
+
+type InflectionTestSuite struct {
+	suite.Suite
+}
+
+func TestInflectionTestSuite(t *testing.T) {
+	suite.Run(t, new(InflectionTestSuite))
+}
+
+func (suite *InflectionTestSuite) TestPluralize() {
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		// Irregulars
+		{"person", "people"},
+		{"child", "children"},
+		{"contact_person", "contact_people"},
+		// -y after a consonant becomes -ies, after a vowel it takes -s
+		{"company", "companies"},
+		{"category", "categories"},
+		{"day", "days"},
+		{"survey", "surveys"},
+		// Sibilants take -es
+		{"address", "addresses"},
+		{"box", "boxes"},
+		{"match", "matches"},
+		{"wish", "wishes"},
+		{"quiz", "quizzes"},
+		{"bus", "buses"},
+		// Other suffix rules
+		{"knife", "knives"},
+		{"shelf", "shelves"},
+		{"analysis", "analyses"},
+		{"index", "indices"},
+		{"medium", "media"},
+		// Regular words take -s
+		{"comment", "comments"},
+		{"order_item", "order_items"},
+		// Already plural or uncountable words are unchanged
+		{"comments", "comments"},
+		{"people", "people"},
+		{"companies", "companies"},
+		{"order_items", "order_items"},
+		{"data", "data"},
+		{"news", "news"},
+		{"information", "information"},
+		{"contact_info", "contact_info"},
+	}
+
+	for _, test := range tests {
+		suite.Equal(test.expected, formatdef.Pluralize(test.word, nil), test.word)
+	}
+}
+
+func (suite *InflectionTestSuite) TestPluralize_Irregulars() {
+	irregulars := map[string]string{
+		"cactus":          "cacti",
+		"staff":           "staff_members",
+		"company_contact": "company_contacts_list",
+	}
+
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{"cactus", "cacti"},
+		{"garden_cactus", "garden_cacti"},
+		// Configured irregulars take precedence over the built-in words
+		{"staff", "staff_members"},
+		// Whole identifiers match before their last word
+		{"company_contact", "company_contacts_list"},
+		{"cacti", "cacti"},
+	}
+
+	for _, test := range tests {
+		suite.Equal(test.expected, formatdef.Pluralize(test.word, irregulars), test.word)
+	}
+}
+
+func (suite *InflectionTestSuite) TestIsPlural() {
+	tests := []struct {
+		word     string
+		expected bool
+	}{
+		{"comments", true},
+		{"companies", true},
+		{"people", true},
+		{"contact_people", true},
+		{"children", true},
+		{"data", true},
+		{"person", false},
+		{"company", false},
+		{"comment", false},
+		// Singular words ending in s
+		{"address", false},
+		{"status", false},
+		{"analysis", false},
+		{"bus", false},
+	}
+
+	for _, test := range tests {
+		suite.Equal(test.expected, formatdef.IsPlural(test.word, nil), test.word)
+	}
+}
+
+func (suite *InflectionTestSuite) TestIsPlural_Irregulars() {
+	irregulars := map[string]string{"cactus": "cacti"}
+
+	suite.True(formatdef.IsPlural("cacti", irregulars))
+	suite.False(formatdef.IsPlural("cactus", irregulars))
+	suite.False(formatdef.IsPlural("cacti", nil))
+}
@@ -0,0 +1,141 @@
+package formatdef
+
+// Schema represents the relational layout behind the generated models
+type Schema struct {
+	Tables []Table   `json:"tables"`
+	Enums  []EnumDef `json:"enums,omitempty"`
+	Views  []View    `json:"views,omitempty"`
+}
+
+// Table represents a database table mapped by a generated model
+type Table struct {
+	Name           string             `json:"name"`
+	Schema         string             `json:"schema,omitempty"`
+	Comment        string             `json:"comment,omitempty"`
+	ModelName      string             `json:"model"`
+	PrimaryKeyName string             `json:"primaryKeyName,omitempty"`
+	Columns        []Column           `json:"columns"`
+	ForeignKeys    []ForeignKey       `json:"foreignKeys,omitempty"`
+	Uniques        []UniqueConstraint `json:"uniques,omitempty"`
+	Indexes        []Index            `json:"indexes,omitempty"`
+}
+
+// Column represents a single table column
+type Column struct {
+	Name          string `json:"name"`                // Database column name
+	Attribute     string `json:"attribute,omitempty"` // Python attribute name on the model
+	Field         string `json:"field,omitempty"`     // Source Morphe field or relation name
+	Composite     string `json:"composite,omitempty"` // Structure mapped over this column group, if any
+	Structure     string `json:"structure,omitempty"` // Structure serialized into this JSON column, if any
+	Type          string `json:"type"`                // SQLAlchemy type name (String, Integer, Enum, ...)
+	EnumName      string `json:"enum,omitempty"`      // Referenced enum when Type is Enum
+	Nullable      bool   `json:"nullable"`
+	PrimaryKey    bool   `json:"primaryKey,omitempty"`
+	AutoIncrement bool   `json:"autoIncrement,omitempty"`
+}
+
+// ForeignKey represents a single-column foreign key constraint
+type ForeignKey struct {
+	Name      string `json:"name"`
+	Column    string `json:"column"`
+	RefSchema string `json:"refSchema,omitempty"`
+	RefTable  string `json:"refTable"`
+	RefColumn string `json:"refColumn"`
+}
+
+// UniqueConstraint represents a unique constraint over one or more columns
+type UniqueConstraint struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+}
+
+// Index represents a non-unique index over one or more columns
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+}
+
+// EnumDef represents a database enum type and its members
+type EnumDef struct {
+	Name     string   `json:"name"`
+	TypeName string   `json:"typeName"` // Database type name
+	Members  []string `json:"members"`
+}
+
+// QualifiedName returns the table name prefixed with its schema, if any
+func (t *Table) QualifiedName() string {
+	return qualifyName(t.Schema, t.Name)
+}
+
+// QualifiedRefTable returns the referenced table name prefixed with its schema, if any
+func (fk ForeignKey) QualifiedRefTable() string {
+	return qualifyName(fk.RefSchema, fk.RefTable)
+}
+
+// qualifyName joins a schema and a table name
+func qualifyName(schema string, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// GetTable returns the table with the given schema-qualified name
+func (s *Schema) GetTable(qualifiedName string) (Table, bool) {
+	for _, table := range s.Tables {
+		if table.QualifiedName() == qualifiedName {
+			return table, true
+		}
+	}
+	return Table{}, false
+}
+
+// GetEnum returns the enum with the given name
+func (s *Schema) GetEnum(name string) (EnumDef, bool) {
+	for _, enum := range s.Enums {
+		if enum.Name == name {
+			return enum, true
+		}
+	}
+	return EnumDef{}, false
+}
+
+// GetColumn returns the column with the given name
+func (t *Table) GetColumn(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// GetForeignKey returns the foreign key constraint on the given column
+func (t *Table) GetForeignKey(column string) (ForeignKey, bool) {
+	for _, fk := range t.ForeignKeys {
+		if fk.Column == column {
+			return fk, true
+		}
+	}
+	return ForeignKey{}, false
+}
+
+// GetUnique returns the unique constraint with the given name
+func (t *Table) GetUnique(name string) (UniqueConstraint, bool) {
+	for _, unique := range t.Uniques {
+		if unique.Name == name {
+			return unique, true
+		}
+	}
+	return UniqueConstraint{}, false
+}
+
+// GetIndex returns the index with the given name
+func (t *Table) GetIndex(name string) (Index, bool) {
+	for _, index := range t.Indexes {
+		if index.Name == name {
+			return index, true
+		}
+	}
+	return Index{}, false
+}
@@ -0,0 +1,64 @@
+package formatdef
+
+// View represents a read-only database view serving an entity
+type View struct {
+	Name       string       `json:"name"`
+	Schema     string       `json:"schema,omitempty"`
+	EntityName string       `json:"entity"`
+	From       string       `json:"from"` // Table of the entity's root model
+	FromSchema string       `json:"fromSchema,omitempty"`
+	Columns    []ViewColumn `json:"columns"`
+	Joins      []ViewJoin   `json:"joins,omitempty"`
+}
+
+// ViewColumn is a view column selected from a column of the root or a joined table
+type ViewColumn struct {
+	Column
+	Source       string `json:"source"`       // Alias of the table the column is selected from
+	SourceColumn string `json:"sourceColumn"` // Column name in that table
+}
+
+// ViewJoin joins a related table into a view
+type ViewJoin struct {
+	Alias        string `json:"alias"`
+	Table        string `json:"table"`
+	Schema       string `json:"schema,omitempty"`
+	Column       string `json:"column"`       // Column of the joined table
+	Source       string `json:"source"`       // Alias the join starts from
+	SourceColumn string `json:"sourceColumn"` // Column of the source matching Column
+	Outer        bool   `json:"outer,omitempty"`
+}
+
+// QualifiedName returns the view name prefixed with its schema, if any
+func (v *View) QualifiedName() string {
+	return qualifyName(v.Schema, v.Name)
+}
+
+// TableNames returns the schema-qualified tables a view selects from
+func (v *View) TableNames() []string {
+	names := []string{qualifyName(v.FromSchema, v.From)}
+	for _, join := range v.Joins {
+		names = append(names, qualifyName(join.Schema, join.Table))
+	}
+	return names
+}
+
+// GetJoin returns the join with the given alias
+func (v *View) GetJoin(alias string) (ViewJoin, bool) {
+	for _, join := range v.Joins {
+		if join.Alias == alias {
+			return join, true
+		}
+	}
+	return ViewJoin{}, false
+}
+
+// GetView returns the view with the given schema-qualified name
+func (s *Schema) GetView(qualifiedName string) (View, bool) {
+	for _, view := range s.Views {
+		if view.QualifiedName() == qualifiedName {
+			return view, true
+		}
+	}
+	return View{}, false
+}
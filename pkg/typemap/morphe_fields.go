@@ -1,6 +1,8 @@
 package typemap
 
 import (
+	"fmt"
+
 	"github.com/kalo-build/morphe-go/pkg/registry"
 	"github.com/kalo-build/morphe-go/pkg/yaml"
 	"github.com/kalo-build/plugin-morphe-sqlalchemy-types/pkg/formatdef"
@@ -46,5 +48,18 @@ func GetFieldType(fieldType yaml.ModelFieldType) formatdef.Type {
 func MorpheStructureFieldToFormatType(fieldType yaml.StructureFieldType, fieldName string, r *registry.Registry) (formatdef.Type, error) {
 	// Structure fields use the same type mappings as model fields
 	modelFieldType := yaml.ModelFieldType(fieldType)
-	return GetFieldType(modelFieldType), nil
+	if formatType, exists := MorpheModelFieldToFormatType[modelFieldType]; exists {
+		return formatType, nil
+	}
+
+	// Enums and other structures are referenced by name
+	if r != nil {
+		if _, err := r.GetEnum(string(fieldType)); err == nil {
+			return formatdef.BasicType{Name: string(fieldType)}, nil
+		}
+		if _, err := r.GetStructure(string(fieldType)); err == nil {
+			return formatdef.BasicType{Name: string(fieldType)}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown type %s of structure field %s", fieldType, fieldName)
 }
@@ -0,0 +1,60 @@
+package typemap
+
+// SQL dialects supported for DDL output
+const (
+	DialectPostgreSQL = "postgresql"
+	DialectMySQL      = "mysql"
+	DialectSQLite     = "sqlite"
+)
+
+// SQLAlchemyTypeToSQL maps SQLAlchemy column types to the DDL type of each dialect
+var SQLAlchemyTypeToSQL = map[string]map[string]string{
+	DialectPostgreSQL: {
+		"String":   "VARCHAR",
+		"Text":     "TEXT",
+		"Integer":  "INTEGER",
+		"Float":    "FLOAT",
+		"Boolean":  "BOOLEAN",
+		"DateTime": "TIMESTAMP WITHOUT TIME ZONE",
+		"Date":     "DATE",
+		"JSON":     "JSON",
+		"JSONB":    "JSONB",
+	},
+	DialectMySQL: {
+		"String":   "VARCHAR(255)",
+		"Text":     "TEXT",
+		"Integer":  "INTEGER",
+		"Float":    "FLOAT",
+		"Boolean":  "BOOL",
+		"DateTime": "DATETIME",
+		"Date":     "DATE",
+		"JSON":     "JSON",
+		"JSONB":    "JSON",
+	},
+	DialectSQLite: {
+		"String":   "VARCHAR",
+		"Text":     "TEXT",
+		"Integer":  "INTEGER",
+		"Float":    "FLOAT",
+		"Boolean":  "BOOLEAN",
+		"DateTime": "DATETIME",
+		"Date":     "DATE",
+		"JSON":     "JSON",
+		"JSONB":    "JSON",
+	},
+}
+
+// IsSupportedDialect checks if DDL can be rendered for a dialect
+func IsSupportedDialect(dialect string) bool {
+	_, exists := SQLAlchemyTypeToSQL[dialect]
+	return exists
+}
+
+// GetSQLType returns the DDL type for a SQLAlchemy column type in the given dialect
+func GetSQLType(dialect string, sqlalchemyType string) string {
+	if sqlType, exists := SQLAlchemyTypeToSQL[dialect][sqlalchemyType]; exists {
+		return sqlType
+	}
+	// Unknown types fall back to the dialect's string type
+	return SQLAlchemyTypeToSQL[dialect]["String"]
+}
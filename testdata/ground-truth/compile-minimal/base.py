@@ -1,12 +1,26 @@
 # Code generated by Morphe
+# Source: Morphe Registry
+# Hash: sha256:08c41dedec3662e01ba2cb30b45bf64a299a3631071d6c2f8437975b8bca1a95
+
 # SQLAlchemy Base definition
 
+from sqlalchemy import MetaData
 from sqlalchemy.ext.declarative import declarative_base
 
+# Constraint naming convention, keeps constraint names stable for Alembic
+NAMING_CONVENTION = {
+    "ck": "ck_%(table_name)s_%(constraint_name)s",
+    "fk": "fk_%(table_name)s_%(column_0_name)s_%(referred_table_name)s",
+    "ix": "ix_%(column_0_label)s",
+    "pk": "pk_%(table_name)s",
+    "uq": "uq_%(table_name)s_%(column_0_N_name)s",
+}
+
+metadata = MetaData(naming_convention=NAMING_CONVENTION)
+
 # Create the declarative base that all models will inherit from
-Base = declarative_base()
+Base = declarative_base(metadata=metadata)
 
 # You can customize the Base class here if needed
 # For example:
 # Base.query = db.session.query_property()
-
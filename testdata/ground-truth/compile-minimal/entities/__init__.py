@@ -1,5 +1,6 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:14c748227cb9530f57ebc02cbcd8540cae78fd0fc4e176441c1daa5f0c3ffc9f
 
 from .company import Company
 from .person import Person
@@ -1,14 +1,19 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:6776a3cf45a5a04dd6b2d4d53d50490c4002779b8dfb7fc26d9e482ffa8407e0
 
-# Code generated by Morphe
 # Entity DTO (Data Transfer Object)
 # Note: Entities are DTOs/ViewModels, not SQLAlchemy ORM models
 
+from sqlalchemy.orm import aliased
+from sqlalchemy import select
 from typing import List, Optional, TYPE_CHECKING
 
 if TYPE_CHECKING:
+    from .. import models
+    from sqlalchemy.sql import Select
     from .person import Person
+    from sqlalchemy.ext.asyncio import AsyncSession
 
 class Company:
     """
@@ -17,17 +22,83 @@ class Company:
     Identifiers: 1
     Relationships: 1
     """
-    # primary identifier
-    id_: int
-    name: str
+    __slots__ = ('_id_', '__dict__')
+    name: Optional[str] = None
     tax_id: Optional[str] = None
-    persons: List[Person] = None
+    people: List['Person'] = None
+
+    def __init__(
+        self,
+        *,
+        id_: int,
+        name: Optional[str] = None,
+        tax_id: Optional[str] = None,
+        people: List['Person'] = None,
+    ) -> None:
+        self._id_ = id_
+        self.name = name
+        self.tax_id = tax_id
+        self.people = people
 
-    def get_id(self) -> str:
+    # primary identifier
+    @property
+    def id_(self) -> int:
+        """Immutable id_."""
+        return self._id_
+
+    def get_id(self) -> int:
         """Get the primary identifier."""
-        return self.id
+        return self.id_
+
+    @classmethod
+    def from_model(cls, company: 'models.Company') -> 'Company':
+        """Create a Company entity from a Company model."""
+        entity = cls.__new__(cls)
+        entity._id_ = company.id_
+        entity.name = company.name
+        entity.tax_id = company.tax_id
+        entity.people = []
+        return entity
+
+    @classmethod
+    def select(cls) -> 'Select':
+        """Select the Company fields as columns labeled like the entity attributes."""
+        from .. import models
+
+        return (
+            select(
+                models.Company.id_.label('id_'),
+                models.Company.name.label('name'),
+                models.Company.tax_id.label('tax_id'),
+            )
+            .select_from(models.Company)
+        )
+
+    @classmethod
+    def from_row(cls, row) -> 'Company':
+        """Create a Company entity from a row returned by select()."""
+        entity = cls.__new__(cls)
+        entity._id_ = row.id_
+        entity.name = row.name
+        entity.tax_id = row.tax_id
+        entity.people = []
+        return entity
+
+    async def load_people(self, session: 'AsyncSession') -> List['Person']:
+        """Load the related Person entities."""
+        from .. import models
+        from .person import Person
+
+        related = aliased(models.Person)
+        keys = (
+            select(related.id_)
+            .select_from(models.Company)
+            .join(models.Company.person.of_type(related))
+            .where(models.Company.id_ == self.id_)
+        )
+        query = Person.select().where(models.Person.id_.in_(keys))
+        result = await session.execute(query)
+        return [Person.from_row(row) for row in result]
 
-    async def load_persons(self) -> List['Person']:
-        """Load related Person entities."""
-        # TODO: Implement lazy loading
-        return []
\ No newline at end of file
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
@@ -1,15 +1,20 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:a84401c1c8ac5612e7bdf9128d2c52caea2fb6c5ca2ac3b284cfd485a4bc397a
 
-# Code generated by Morphe
 # Entity DTO (Data Transfer Object)
 # Note: Entities are DTOs/ViewModels, not SQLAlchemy ORM models
 
+from sqlalchemy.orm import aliased
+from sqlalchemy import select
 from typing import List, Optional, TYPE_CHECKING
 from ..enums.nationality import Nationality
 
 if TYPE_CHECKING:
+    from .. import models
+    from sqlalchemy.sql import Select
     from .company import Company
+    from sqlalchemy.ext.asyncio import AsyncSession
 
 class Person:
     """
@@ -18,19 +23,97 @@ class Person:
     Identifiers: 1
     Relationships: 1
     """
-    email: str
-    # primary identifier
-    id_: int
-    last_name: str
-    nationality: Nationality
+    __slots__ = ('_id_', '__dict__')
+    email: Optional[str] = None
+    last_name: Optional[str] = None
+    nationality: Optional[Nationality] = None
     company_id: Optional[str] = None
-    company: Company
+    company: Optional['Company'] = None
+
+    def __init__(
+        self,
+        *,
+        email: Optional[str] = None,
+        id_: int,
+        last_name: Optional[str] = None,
+        nationality: Optional[Nationality] = None,
+        company_id: Optional[str] = None,
+        company: Optional['Company'] = None,
+    ) -> None:
+        self.email = email
+        self._id_ = id_
+        self.last_name = last_name
+        self.nationality = nationality
+        self.company_id = company_id
+        self.company = company
 
-    def get_id(self) -> str:
+    # primary identifier
+    @property
+    def id_(self) -> int:
+        """Immutable id_."""
+        return self._id_
+
+    def get_id(self) -> int:
         """Get the primary identifier."""
-        return self.id
+        return self.id_
+
+    @classmethod
+    def from_model(cls, person: 'models.Person') -> 'Person':
+        """Create a Person entity from a Person model."""
+        entity = cls.__new__(cls)
+        entity.email = person.contact_info.email if person.contact_info is not None else None
+        entity._id_ = person.id_
+        entity.last_name = person.last_name
+        entity.nationality = person.nationality
+        entity.company_id = person.company_id
+        entity.company = None
+        return entity
+
+    @classmethod
+    def select(cls) -> 'Select':
+        """Select the Person fields as columns labeled like the entity attributes."""
+        from .. import models
+
+        contact_info = aliased(models.ContactInfo)
+        return (
+            select(
+                contact_info.email.label('email'),
+                models.Person.id_.label('id_'),
+                models.Person.last_name.label('last_name'),
+                models.Person.nationality.label('nationality'),
+                models.Person.company_id.label('company_id'),
+            )
+            .select_from(models.Person)
+            .outerjoin(models.Person.contact_info.of_type(contact_info))
+        )
+
+    @classmethod
+    def from_row(cls, row) -> 'Person':
+        """Create a Person entity from a row returned by select()."""
+        entity = cls.__new__(cls)
+        entity.email = row.email
+        entity._id_ = row.id_
+        entity.last_name = row.last_name
+        entity.nationality = row.nationality
+        entity.company_id = row.company_id
+        entity.company = None
+        return entity
+
+    async def load_company(self, session: 'AsyncSession') -> Optional['Company']:
+        """Load the related Company entity."""
+        from .. import models
+        from .company import Company
+
+        related = aliased(models.Company)
+        keys = (
+            select(related.id_)
+            .select_from(models.Person)
+            .join(models.Person.company.of_type(related))
+            .where(models.Person.id_ == self.id_)
+        )
+        query = Company.select().where(models.Company.id_.in_(keys))
+        row = (await session.execute(query)).first()
+        return Company.from_row(row) if row is not None else None
 
-    async def load_company(self) -> Optional['Company']:
-        """Load related Company entity."""
-        # TODO: Implement lazy loading
-        return None
\ No newline at end of file
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
@@ -1,5 +1,6 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:3ebb4b2ee81f4b4066c34bd1ca4ae06c4de5a338f0ac1fed876f55946cbcc26b
 
 from .nationality import Nationality
 from .universal_number import UniversalNumber
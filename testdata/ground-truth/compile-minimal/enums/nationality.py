@@ -1,5 +1,6 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:5388c5279302d381bb21c6f9cd989f32c39fa9606b5f63cce923a9bd39d6ffc9
 
 from enum import Enum
 
@@ -16,4 +17,7 @@ class Nationality(Enum):
         for member in cls:
             if member.value == value:
                 return member
-        raise ValueError(f"No Nationality member with value {value}")
\ No newline at end of file
+        raise ValueError(f"No Nationality member with value {value}")
+
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
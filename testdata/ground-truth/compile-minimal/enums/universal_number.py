@@ -1,5 +1,6 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:932027488d68fa4db13af70b09f0fd9aee3916ebaf6ab8401e7a74f2a04b66f7
 
 from enum import Enum
 
@@ -15,4 +16,7 @@ class UniversalNumber(Enum):
         for member in cls:
             if member.value == value:
                 return member
-        raise ValueError(f"No UniversalNumber member with value {value}")
\ No newline at end of file
+        raise ValueError(f"No UniversalNumber member with value {value}")
+
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
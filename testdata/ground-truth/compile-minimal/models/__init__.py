@@ -1,5 +1,6 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:14b64f272014324f5434cff07db40dac3a2ca8cc7405fb032f96bd6bbcbfa524
 
 from .company import Company
 from .contact_info import ContactInfo
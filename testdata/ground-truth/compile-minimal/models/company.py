@@ -1,14 +1,14 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:f55d763ce372b8b9dac0329aa4f575eb8ccc6c95ef776e8445b061b5eb188e53
 
-# Code generated by Morphe
 # SQLAlchemy model definition
 # Note: This requires a Base class defined as:
 #   from sqlalchemy.ext.declarative import declarative_base
 #   Base = declarative_base()
 
 from .base import Base
-from sqlalchemy import Column, Integer, String, Text, Float, Boolean, DateTime, Date, ForeignKey, JSON, relationship
+from sqlalchemy import Column, Integer, String, Text, Float, Boolean, DateTime, Date, ForeignKey, JSON, relationship, UniqueConstraint
 from typing import List, Optional, TYPE_CHECKING
 
 if TYPE_CHECKING:
@@ -16,10 +16,16 @@ if TYPE_CHECKING:
 
 class Company(Base):
     __tablename__ = 'company'
+    __table_args__ = (
+        UniqueConstraint('name', name='uq_company_name'),
+    )
 
     """Company model."""
-    id_ = Column(Integer, primary_key=True, autoincrement=True)
-    name = Column(String, nullable=False)
-    tax_id = Column(Integer, ForeignKey('tax.id'), nullable=False)
+    id_ = Column('id', Integer, primary_key=True, autoincrement=True)
+    name = Column('name', String, nullable=False)
+    tax_id = Column('tax_id', String, nullable=False)
+
+    person = relationship("Person", back_populates="company")
 
-    person = relationship("Person", back_populates="company")
\ No newline at end of file
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
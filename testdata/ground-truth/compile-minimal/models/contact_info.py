@@ -1,14 +1,14 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:3a96ee80524f16137f3e8c9e823aa6c6a2a8df59b716e5fdd8abb317dee3a8f0
 
-# Code generated by Morphe
 # SQLAlchemy model definition
 # Note: This requires a Base class defined as:
 #   from sqlalchemy.ext.declarative import declarative_base
 #   Base = declarative_base()
 
 from .base import Base
-from sqlalchemy import Column, Integer, String, Text, Float, Boolean, DateTime, Date, ForeignKey, JSON, relationship
+from sqlalchemy import Column, Integer, String, Text, Float, Boolean, DateTime, Date, ForeignKey, JSON, relationship, UniqueConstraint
 from typing import Optional, TYPE_CHECKING
 
 if TYPE_CHECKING:
@@ -16,10 +16,16 @@ if TYPE_CHECKING:
 
 class ContactInfo(Base):
     __tablename__ = 'contact_info'
+    __table_args__ = (
+        UniqueConstraint('email', name='uq_contact_info_email'),
+    )
 
     """ContactInfo model."""
-    email = Column(String, nullable=False)
-    id_ = Column(Integer, primary_key=True, autoincrement=True)
-    person_id = Column(Integer, ForeignKey('person.id'), nullable=False)
+    email = Column('email', String, nullable=False)
+    id_ = Column('id', Integer, primary_key=True, autoincrement=True)
+    person_id = Column('person_id', Integer, ForeignKey('person.id', link_to_name=True), index=True, nullable=False)
+
+    person = relationship("Person", back_populates="contact_info")
 
-    person = relationship("Person", back_populates="contact_info")
\ No newline at end of file
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
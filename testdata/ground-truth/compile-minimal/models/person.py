@@ -1,16 +1,18 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:0d1f025589354cf838a87052d1d7129d73cb6c7c2b416d5b1845c389f2183937
 
-# Code generated by Morphe
 # SQLAlchemy model definition
 # Note: This requires a Base class defined as:
 #   from sqlalchemy.ext.declarative import declarative_base
 #   Base = declarative_base()
 
 from .base import Base
-from sqlalchemy import Column, Integer, String, Text, Float, Boolean, DateTime, Date, ForeignKey, JSON, relationship, Enum
+from sqlalchemy.orm import composite
+from sqlalchemy import Column, Integer, String, Text, Float, Boolean, DateTime, Date, ForeignKey, JSON, relationship, UniqueConstraint, Enum
 from typing import Optional, TYPE_CHECKING
 from ..enums.nationality import Nationality
+from ..structures.address import Address
 
 if TYPE_CHECKING:
     from .company import Company
@@ -18,13 +20,25 @@ if TYPE_CHECKING:
 
 class Person(Base):
     __tablename__ = 'person'
+    __table_args__ = (
+        UniqueConstraint('first_name', 'last_name', name='uq_person_first_name_last_name'),
+    )
 
     """Person model."""
-    first_name = Column(String, nullable=False)
-    id_ = Column(Integer, primary_key=True, autoincrement=True)
-    last_name = Column(String, nullable=False)
-    nationality = Column(Enum(Nationality), nullable=False)
-    company_id = Column(Integer, ForeignKey('company.id'), nullable=False)
+    first_name = Column('first_name', String, nullable=False)
+    home_address_city = Column('home_address_city', String, nullable=False)
+    home_address_house_nr = Column('home_address_house_nr', String, nullable=False)
+    home_address_street = Column('home_address_street', String, nullable=False)
+    home_address_zip_code = Column('home_address_zip_code', String, nullable=False)
+    id_ = Column('id', Integer, primary_key=True, autoincrement=True)
+    last_name = Column('last_name', String, nullable=False)
+    nationality = Column('nationality', Enum(Nationality), nullable=False)
+    company_id = Column('company_id', Integer, ForeignKey('company.id', link_to_name=True), index=True, nullable=False)
+
+    home_address = composite(Address, home_address_city, home_address_house_nr, home_address_street, home_address_zip_code)
 
     company = relationship("Company", back_populates="person")
-    contact_info = relationship("ContactInfo", back_populates="person")
\ No newline at end of file
+    contact_info = relationship("ContactInfo", back_populates="person", uselist=False)
+
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
@@ -0,0 +1,40 @@
+-- Code generated by Morphe
+-- Dialect: postgresql
+
+CREATE TYPE nationality AS ENUM ('DE', 'FR', 'US');
+
+CREATE TABLE company (
+    id SERIAL NOT NULL,
+    name VARCHAR NOT NULL,
+    tax_id VARCHAR NOT NULL,
+    CONSTRAINT pk_company PRIMARY KEY (id),
+    CONSTRAINT uq_company_name UNIQUE (name)
+);
+
+CREATE TABLE person (
+    first_name VARCHAR NOT NULL,
+    home_address_city VARCHAR NOT NULL,
+    home_address_house_nr VARCHAR NOT NULL,
+    home_address_street VARCHAR NOT NULL,
+    home_address_zip_code VARCHAR NOT NULL,
+    id SERIAL NOT NULL,
+    last_name VARCHAR NOT NULL,
+    nationality nationality NOT NULL,
+    company_id INTEGER NOT NULL,
+    CONSTRAINT pk_person PRIMARY KEY (id),
+    CONSTRAINT uq_person_first_name_last_name UNIQUE (first_name, last_name),
+    CONSTRAINT fk_person_company_id_company FOREIGN KEY (company_id) REFERENCES company (id)
+);
+
+CREATE TABLE contact_info (
+    email VARCHAR NOT NULL,
+    id SERIAL NOT NULL,
+    person_id INTEGER NOT NULL,
+    CONSTRAINT pk_contact_info PRIMARY KEY (id),
+    CONSTRAINT uq_contact_info_email UNIQUE (email),
+    CONSTRAINT fk_contact_info_person_id_person FOREIGN KEY (person_id) REFERENCES person (id)
+);
+
+CREATE INDEX ix_person_company_id ON person (company_id);
+
+CREATE INDEX ix_contact_info_person_id ON contact_info (person_id);
\ No newline at end of file
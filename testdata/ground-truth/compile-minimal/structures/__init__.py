@@ -1,4 +1,6 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:9fced71cfbdfa657a8404c12236353b8bfc924ab35887293a651e976d45d637a
 
 from .address import Address
+from .delivery import Delivery
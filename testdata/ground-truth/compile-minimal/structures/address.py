@@ -1,7 +1,7 @@
 # Code generated by Morphe
 # Source: Morphe Registry
+# Hash: sha256:d0e25fded3ee9aebda86f95e86ba99fae9f684b7d2b1fc7a3163e7d532279cd0
 
-# Code generated by Morphe
 # Structure DTO (Data Transfer Object)
 
 from typing import Optional
@@ -12,4 +12,24 @@ class Address:
     city: str
     house_nr: str
     street: str
-    zip_code: str
\ No newline at end of file
+    zip_code: str
+
+    def __init__(self, city: str, house_nr: str, street: str, zip_code: str):
+        self.city = city
+        self.house_nr = house_nr
+        self.street = street
+        self.zip_code = zip_code
+
+    def __composite_values__(self):
+        return (self.city, self.house_nr, self.street, self.zip_code)
+
+    def __eq__(self, other):
+        if not isinstance(other, Address):
+            return NotImplemented
+        return (self.city, self.house_nr, self.street, self.zip_code) == (other.city, other.house_nr, other.street, other.zip_code)
+
+    def __repr__(self):
+        return f"Address(city={self.city!r}, house_nr={self.house_nr!r}, street={self.street!r}, zip_code={self.zip_code!r})"
+
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
@@ -0,0 +1,32 @@
+# Code generated by Morphe
+# Source: Morphe Registry
+# Hash: sha256:21e9d61cf657705851cf12b616219cd49e2f70d77c7e3ef0191dcbc194db384b
+
+# Structure DTO (Data Transfer Object)
+
+from typing import Optional
+from ..enums.nationality import Nationality
+from .address import Address
+
+
+class Delivery:
+    """Delivery data transfer object."""
+    recipient: str
+    recipient_nationality: Nationality
+    shipping_address: Address
+
+    def __init__(self, recipient: str, recipient_nationality: Nationality, shipping_address: Address):
+        self.recipient = recipient
+        self.recipient_nationality = recipient_nationality
+        self.shipping_address = shipping_address
+
+    def __eq__(self, other):
+        if not isinstance(other, Delivery):
+            return NotImplemented
+        return (self.recipient, self.recipient_nationality, self.shipping_address) == (other.recipient, other.recipient_nationality, other.shipping_address)
+
+    def __repr__(self):
+        return f"Delivery(recipient={self.recipient!r}, recipient_nationality={self.recipient_nationality!r}, shipping_address={self.shipping_address!r})"
+
+    # morphe:custom-begin
+    # morphe:custom-end
\ No newline at end of file
@@ -0,0 +1,13 @@
+name: Department
+fields:
+  ID:
+    type: AutoIncrement
+    attributes:
+      - mandatory
+  Name:
+    type: String
+identifiers:
+  primary: ID
+related:
+  Employee:
+    type: ForOne
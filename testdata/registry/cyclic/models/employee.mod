@@ -0,0 +1,13 @@
+name: Employee
+fields:
+  ID:
+    type: AutoIncrement
+    attributes:
+      - mandatory
+  Name:
+    type: String
+identifiers:
+  primary: ID
+related:
+  Department:
+    type: ForOne
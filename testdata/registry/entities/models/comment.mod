@@ -0,0 +1,21 @@
+name: Comment
+fields:
+  ID:
+    type: AutoIncrement
+    attributes:
+      - mandatory
+  Body:
+    type: String
+  Slug:
+    type: String
+  Status:
+    type: Status
+identifiers:
+  primary: ID
+  slug: Slug
+related:
+  Commentable:
+    type: ForOnePoly
+    for:
+      - Person
+      - Company
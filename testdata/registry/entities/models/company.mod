@@ -0,0 +1,14 @@
+name: Company
+fields:
+  ID:
+    type: UUID
+    attributes:
+      - mandatory
+  Name:
+    type: String
+identifiers:
+  primary: ID
+related:
+  Comment:
+    type: HasManyPoly
+    through: Commentable
@@ -0,0 +1,19 @@
+name: Person
+fields:
+  ID:
+    type: AutoIncrement
+    attributes:
+      - mandatory
+  FirstName:
+    type: String
+  LastName:
+    type: String
+identifiers:
+  primary: ID
+  name:
+    - FirstName
+    - LastName
+related:
+  Comment:
+    type: HasManyPoly
+    through: Commentable
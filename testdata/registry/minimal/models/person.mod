@@ -10,6 +10,8 @@ fields:
     type: String
   Nationality:
     type: Nationality
+  HomeAddress:
+    type: Address
 identifiers:
   primary: ID
   name: